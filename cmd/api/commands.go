@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"khalif-comment/internal/config"
+	"khalif-comment/internal/domain"
+	"khalif-comment/internal/repository"
+	"khalif-comment/internal/usecase"
+	"khalif-comment/pkg/database"
+
+)
+
+const commandUsage = `Usage:
+  server                     Start the HTTP server
+  server migrate up          Apply all pending migrations
+  server migrate down [n]    Roll back the last n migrations (default 1)
+  server migrate redo        Roll back and re-apply the last migration
+  server migrate status      Show applied / pending migrations
+  server reset -confirm=<db name> [-snapshot=backup.jsonl]
+                             Drop all data and re-apply migrations (APP_ENV=development|local|test only)
+  server cache flush         Delete every cached comment list, stats and count from Redis
+  server content render [-all]
+                             Render content_html for comments that have none (-all: re-render every comment), then flush the cache
+  server apikey create -service=<name> -scopes=<scope,...>
+                             Create an API key for /internal endpoints (printed once)
+  server apikey list         List API keys (prefix, service, scopes, last used)
+  server apikey revoke <prefix>
+                             Revoke an API key`
+
+// runCommand menjalankan subcommand CLI dan mengembalikan exit code
+func runCommand(cfg *config.Config, args []string) int {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(cfg, args[1:])
+	case "reset":
+		return runReset(cfg, args[1:])
+	case "cache":
+		return runCache(cfg, args[1:])
+	case "apikey":
+		return runAPIKey(cfg, args[1:])
+	case "content":
+		return runContent(cfg, args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, commandUsage)
+		return 2
+	}
+}
+
+func runMigrate(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, commandUsage)
+		return 2
+	}
+
+	m, err := database.NewMigrator(ProvideDB(cfg))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "load migrations:", err)
+		return 1
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "migrate up:", err)
+			return 1
+		}
+		fmt.Printf("applied %d migration(s)\n", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				fmt.Fprintln(os.Stderr, "down: n must be a positive number")
+				return 2
+			}
+		}
+		reverted, err := m.Down(ctx, steps)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "migrate down:", err)
+			return 1
+		}
+		fmt.Printf("reverted %d migration(s)\n", reverted)
+
+	case "redo":
+		if err := m.Redo(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, "migrate redo:", err)
+			return 1
+		}
+		fmt.Println("redo done")
+
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "migrate status:", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			state, appliedAt := "pending", "-"
+			if s.AppliedAt != nil {
+				state, appliedAt = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if s.Modified {
+				state = "applied (MODIFIED)"
+			}
+			fmt.Fprintf(w, "%03d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		w.Flush()
+
+	default:
+		fmt.Fprintln(os.Stderr, commandUsage)
+		return 2
+	}
+
+	return 0
+}
+
+// runReset pengganti flag -refresh lama. Berlapis pengaman karena DROP SCHEMA tidak bisa dibatalkan:
+// environment harus non-production, nama database harus diketik ulang, dan data bisa di-snapshot dulu
+func runReset(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("reset", flag.ContinueOnError)
+	confirm := fs.String("confirm", "", "Name of the database to reset, must match the connected database")
+	snapshot := fs.String("snapshot", "", "Write every row to this JSONL file before resetting")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if !cfg.IsNonProduction() {
+		fmt.Fprintf(os.Stderr, "reset refused: APP_ENV=%q, set APP_ENV to development, local or test\n", cfg.AppEnv)
+		return 1
+	}
+
+	db := ProvideDB(cfg)
+	ctx := context.Background()
+
+	var dbName string
+	if err := db.WithContext(ctx).Raw("SELECT current_database()").Scan(&dbName).Error; err != nil {
+		fmt.Fprintln(os.Stderr, "reset:", err)
+		return 1
+	}
+	if *confirm != dbName {
+		fmt.Fprintf(os.Stderr, "reset refused: pass -confirm=%s to reset this database\n", dbName)
+		return 1
+	}
+
+	if *snapshot != "" {
+		// O_EXCL: jangan pernah menimpa snapshot sebelumnya
+		f, err := os.OpenFile(*snapshot, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "snapshot:", err)
+			return 1
+		}
+		rows, err := database.Snapshot(ctx, db, f)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "snapshot failed, database NOT reset:", err)
+			return 1
+		}
+		fmt.Printf("snapshot: %d row(s) written to %s\n", rows, *snapshot)
+	}
+
+	if err := database.ResetSchema(db); err != nil {
+		fmt.Fprintln(os.Stderr, "reset:", err)
+		return 1
+	}
+
+	m, err := database.NewMigrator(db)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "load migrations:", err)
+		return 1
+	}
+	applied, err := m.Up(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate up:", err)
+		return 1
+	}
+
+	fmt.Printf("database %s reset, %d migration(s) applied\n", dbName, applied)
+
+	// Cache masih berisi komentar lama, tapi database sudah bersih jadi kegagalan di sini cukup peringatan
+	if err := usecase.FlushCache(ctx, repository.NewCacheRepository(ProvideRedis(cfg), nil)); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: cache flush failed, run `server cache flush` manually:", err)
+	}
+	return 0
+}
+
+func runCache(cfg *config.Config, args []string) int {
+	if len(args) != 1 || args[0] != "flush" {
+		fmt.Fprintln(os.Stderr, commandUsage)
+		return 2
+	}
+
+	if err := usecase.FlushCache(context.Background(), repository.NewCacheRepository(ProvideRedis(cfg), nil)); err != nil {
+		fmt.Fprintln(os.Stderr, "cache flush:", err)
+		return 1
+	}
+	fmt.Println("comment cache flushed")
+	return 0
+}
+
+// runContent: Backfill content_html setelah migrasi 010 atau setelah aturan renderer Markdown berubah
+func runContent(cfg *config.Config, args []string) int {
+	if len(args) == 0 || args[0] != "render" {
+		fmt.Fprintln(os.Stderr, commandUsage)
+		return 2
+	}
+	fs := flag.NewFlagSet("content render", flag.ContinueOnError)
+	all := fs.Bool("all", false, "Re-render every comment, not only those without content_html")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	ctx := context.Background()
+	rendered, err := usecase.RenderContent(ctx, repository.NewCommentRepository(ProvideDB(cfg), nil), *all)
+	fmt.Printf("%d comments rendered\n", rendered)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "content render:", err)
+		return 1
+	}
+
+	// Cache masih berisi komentar dengan content_html lama
+	if err := usecase.FlushCache(ctx, repository.NewCacheRepository(ProvideRedis(cfg), nil)); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: cache flush failed, run `server cache flush` manually:", err)
+	}
+	return 0
+}
+
+// runAPIKey mengelola API key service-to-service. Key plaintext hanya dicetak sekali saat create
+func runAPIKey(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, commandUsage)
+		return 2
+	}
+
+	uc := usecase.NewAPIKeyUseCase(
+		repository.NewAPIKeyRepository(ProvideDB(cfg)),
+		repository.NewCacheRepository(ProvideRedis(cfg), nil),
+	)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+		service := fs.String("service", "", "Name of the calling service, e.g. khalif-stories")
+		scopes := fs.String("scopes", "", fmt.Sprintf("Comma separated scopes: %s, %s, %s, %s, %s",
+			domain.ScopeCommentsRead, domain.ScopeCommentsDelete, domain.ScopeRevocationsWrite, domain.ScopeUsersErase, domain.ScopeMetricsRead))
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+
+		key, plaintext, err := uc.Create(ctx, *service, strings.Split(*scopes, ","))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "apikey create:", err)
+			return 1
+		}
+		fmt.Printf("created key %s for %s (%s)\n", key.Prefix, key.Service, strings.Join(key.Scopes, ","))
+		fmt.Println("store this key now, it cannot be shown again:")
+		fmt.Println(plaintext)
+
+	case "list":
+		keys, err := uc.List(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "apikey list:", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PREFIX\tSERVICE\tSCOPES\tCREATED AT\tLAST USED\tSTATUS")
+		for _, k := range keys {
+			lastUsed, status := "-", "active"
+			if k.LastUsedAt != nil {
+				lastUsed = k.LastUsedAt.Format("2006-01-02 15:04:05")
+			}
+			if k.RevokedAt != nil {
+				status = "revoked " + k.RevokedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", k.Prefix, k.Service, strings.Join(k.Scopes, ","),
+				k.CreatedAt.Format("2006-01-02 15:04:05"), lastUsed, status)
+		}
+		w.Flush()
+
+	case "revoke":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, commandUsage)
+			return 2
+		}
+		if err := uc.Revoke(ctx, args[1]); err != nil {
+			fmt.Fprintln(os.Stderr, "apikey revoke:", err)
+			return 1
+		}
+		fmt.Printf("key %s revoked\n", args[1])
+
+	default:
+		fmt.Fprintln(os.Stderr, commandUsage)
+		return 2
+	}
+
+	return 0
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"khalif-comment/pkg/utils"
+
+)
+
+// ReadinessStatus: Kondisi dependency service. Redis & replica yang bermasalah hanya membuat
+// status "degraded" (service tetap melayani dari DB), database primary yang down membuat "unavailable"
+type ReadinessStatus struct {
+	Status   string `json:"status" example:"degraded"`
+	Database string `json:"database" example:"up"`
+	Replica  string `json:"replica" example:"disabled"`
+	Redis    string `json:"redis" example:"down"`
+}
+
+// Liveness godoc
+// @Summary      Liveness probe
+// @Description  Always 200 while the process is running
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  utils.APIResponse
+// @Router       /healthz [get]
+func Liveness(c *gin.Context) {
+	utils.SuccessMessage(c, http.StatusOK, "ok")
+}
+
+// Readiness godoc
+// @Summary      Readiness probe
+// @Description  503 only when the primary database is unreachable. Redis or replica outages are reported as degraded with status 200
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  ReadinessStatus
+// @Failure      503  {object}  utils.APIResponse
+// @Router       /readyz [get]
+func Readiness(app *App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := ReadinessStatus{
+			Status:   "ok",
+			Database: "up",
+			Replica:  app.Replica.Status(),
+			Redis:    "up",
+		}
+
+		if !app.RedisHealth.Available() {
+			status.Redis = "down"
+			status.Status = "degraded"
+		}
+		if status.Replica == "fallback" {
+			status.Status = "degraded"
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), time.Second)
+		defer cancel()
+
+		sqlDB, err := app.DB.DB()
+		if err == nil {
+			err = sqlDB.PingContext(ctx)
+		}
+		if err != nil {
+			status.Database = "down"
+			status.Status = "unavailable"
+			c.JSON(http.StatusServiceUnavailable, utils.APIResponse{Error: "database unavailable", ErrorCode: utils.CodeServiceUnavailable, Data: status})
+			return
+		}
+
+		utils.SuccessResponse(c, http.StatusOK, status)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	"khalif-comment/internal/domain"
+	"khalif-comment/pkg/logger"
+	"khalif-comment/pkg/requestctx"
+	"khalif-comment/pkg/stream"
+
+)
+
+// runCacheInvalidationListener menjaga L1 cache instance ini sinkron dengan instance lain
+func runCacheInvalidationListener(ctx context.Context, uc domain.CommentUseCase) {
+	if err := uc.ListenInvalidations(ctx); err != nil && ctx.Err() == nil {
+		logger.Error("Cache invalidation listener stopped", zap.Error(err))
+	}
+}
+
+// runStoryEventConsumer memproses event lifecycle story dari khalif-stories.
+// Tipe event lain di stream yang sama di-ack tanpa diproses
+func runStoryEventConsumer(ctx context.Context, consumer *stream.Consumer, uc domain.CommentUseCase) {
+	if consumer == nil {
+		logger.Info("Story event consumer disabled")
+		return
+	}
+
+	err := consumer.Run(ctx, func(ctx context.Context, msg stream.Message) error {
+		if msg.String("type") != "story.deleted" {
+			return nil
+		}
+		storyUUID := msg.String("story_uuid")
+		if storyUUID == "" {
+			return stream.Permanent(errors.New("story.deleted event without story_uuid"))
+		}
+		_, err := uc.ArchiveStory(eventContext(ctx, msg, "story-events"), storyUUID, domain.ArchiveReasonStoryDeleted)
+		return err
+	})
+	if err != nil && ctx.Err() == nil {
+		logger.Error("Story event consumer stopped", zap.Error(err))
+	}
+}
+
+// runUserEventConsumer menjalankan penghapusan data user saat akunnya dihapus di service auth
+func runUserEventConsumer(ctx context.Context, consumer *stream.Consumer, uc domain.CommentUseCase) {
+	if consumer == nil {
+		logger.Info("User event consumer disabled")
+		return
+	}
+
+	err := consumer.Run(ctx, func(ctx context.Context, msg stream.Message) error {
+		if msg.String("type") != "user.deleted" {
+			return nil
+		}
+		userID := msg.String("user_id")
+		if userID == "" {
+			return stream.Permanent(errors.New("user.deleted event without user_id"))
+		}
+		_, err := uc.EraseUser(eventContext(ctx, msg, "user-events"), userID)
+		return err
+	})
+	if err != nil && ctx.Err() == nil {
+		logger.Error("User event consumer stopped", zap.Error(err))
+	}
+}
+
+// eventContext: Aksi dari consumer dicatat di audit log sebagai system, request_id = id pesan stream
+func eventContext(ctx context.Context, msg stream.Message, consumer string) context.Context {
+	return requestctx.With(ctx, requestctx.Info{
+		RequestID: msg.ID,
+		ActorType: requestctx.ActorSystem,
+		ActorID:   consumer,
+	})
+}
+
+// runAuditRetention menghapus audit log yang lebih tua dari AUDIT_RETENTION secara berkala
+func runAuditRetention(ctx context.Context, uc domain.AuditUseCase, interval time.Duration) {
+	if interval <= 0 {
+		logger.Info("Audit retention disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := uc.ApplyRetention(ctx)
+			if err != nil {
+				logger.Error("Failed to apply audit retention", zap.Int64("deleted", deleted), zap.Error(err))
+				continue
+			}
+			if deleted > 0 {
+				logger.Info("Audit events expired", zap.Int64("deleted", deleted))
+			}
+		}
+	}
+}
+
+// runStatsReconciler menjalankan ReconcileCounts secara berkala sampai ctx dibatalkan.
+// Trigger DB sudah menjaga story_comment_stats, job ini hanya jaring pengaman untuk drift
+func runStatsReconciler(ctx context.Context, uc domain.CommentUseCase, interval time.Duration) {
+	if interval <= 0 {
+		logger.Info("Stats reconciler disabled")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fixed, err := uc.ReconcileCounts(ctx)
+			if err != nil {
+				logger.Error("Failed to reconcile story comment stats", zap.Error(err))
+				continue
+			}
+			if fixed > 0 {
+				logger.Info("Story comment stats reconciled", zap.Int("stories_fixed", fixed))
+			}
+		}
+	}
+}
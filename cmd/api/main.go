@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"os"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
@@ -11,8 +13,12 @@ import (
 	"khalif-comment/internal/config"
 	"khalif-comment/internal/domain"
 	"khalif-comment/internal/handler"
+	"khalif-comment/pkg/auth"
 	"khalif-comment/pkg/database"
+	"khalif-comment/pkg/health"
+	"khalif-comment/pkg/i18n"
 	"khalif-comment/pkg/logger"
+	"khalif-comment/pkg/stream"
 
 )
 
@@ -32,28 +38,57 @@ import (
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 type App struct {
 	DB             *gorm.DB
+	Replica        *database.Replica
 	RDB            *redis.Client
+	RedisHealth    *health.RedisMonitor
+	JWT            *auth.Verifier
+	Revocations    *auth.Revocations
+	CommentUC      domain.CommentUseCase // Dipakai background job
+	APIKeyUC       domain.APIKeyUseCase  // Autentikasi endpoint /internal
+	AuditUC        domain.AuditUseCase   // Job retensi audit log
 	CommentHandler *handler.CommentHandler
+	AuthHandler    *handler.AuthHandler
+	AuditHandler   *handler.AuditHandler
 }
 
-// NewApp hanya menerima CommentHandler
-func NewApp(db *gorm.DB, rdb *redis.Client, ch *handler.CommentHandler) *App {
+func NewApp(db *gorm.DB, replica *database.Replica, rdb *redis.Client, redisHealth *health.RedisMonitor, jwt *auth.Verifier, revocations *auth.Revocations, uc domain.CommentUseCase, apiKeys domain.APIKeyUseCase, audit domain.AuditUseCase, ch *handler.CommentHandler, ah *handler.AuthHandler, audh *handler.AuditHandler) *App {
 	return &App{
 		DB:             db,
+		Replica:        replica,
 		RDB:            rdb,
+		RedisHealth:    redisHealth,
+		JWT:            jwt,
+		Revocations:    revocations,
+		CommentUC:      uc,
+		APIKeyUC:       apiKeys,
+		AuditUC:        audit,
 		CommentHandler: ch,
+		AuthHandler:    ah,
+		AuditHandler:   audh,
 	}
 }
 
 func main() {
 	logger.Init()
 
-	refreshFlag := flag.Bool("refresh", false, "Reset Database")
 	flag.Parse()
 
 	cfg := config.LoadConfig()
+
+	if err := i18n.SetDefault(cfg.DefaultLanguage); err != nil {
+		logger.Fatal("Invalid DEFAULT_LANGUAGE", zap.Error(err))
+	}
+
+	// Subcommand CLI, contoh: ./server migrate status
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(cfg, flag.Args()))
+	}
 	
 	// InitializeApp dipanggil dari wire_gen.go (Dependency Injection)
 	app, err := InitializeApp()
@@ -61,21 +96,41 @@ func main() {
 		logger.Fatal("Failed to initialize app", zap.Error(err))
 	}
 
-	if *refreshFlag {
-		database.ResetSchema(app.DB)
-		logger.Info("Database reset successfully")
-	}
-
-	// AutoMigrate hanya untuk entitas Comment
-	if err := app.DB.AutoMigrate(&domain.Comment{}); err != nil {
-		logger.Fatal("Failed to migrate database", zap.Error(err))
+	// Schema sepenuhnya dikelola migrasi SQL berversi (pkg/database/schema), bukan AutoMigrate.
+	// Aman dijalankan banyak pod sekaligus karena dilindungi advisory lock
+	if cfg.MigrateOnStart {
+		database.RunMigrations(app.DB)
 	}
 
-	// Menjalankan migrasi SQL tambahan (seperti trigger updated_at)
-	database.RunMigrations(app.DB)
-
 	// Seeder dihapus karena komentar tidak butuh data awal
 
+	// Background job: rekonsiliasi jumlah komentar per story
+	go runStatsReconciler(context.Background(), app.CommentUC, cfg.StatsReconcileInterval)
+
+	// Background job: evict L1 cache saat instance lain meng-invalidate story
+	go runCacheInvalidationListener(context.Background(), app.CommentUC)
+
+	// Background job: arsipkan komentar story yang dihapus di khalif-stories
+	storyEvents := newEventConsumer(app.RDB, app.RedisHealth, stream.Options{
+		Stream:     cfg.StoryEventsStream,
+		Group:      cfg.StoryEventsGroup,
+		MaxRetries: cfg.StoryEventsMaxRetries,
+		RetryIdle:  cfg.StoryEventsRetryIdle,
+	})
+	go runStoryEventConsumer(context.Background(), storyEvents, app.CommentUC)
+
+	// Background job: hapus data user yang akunnya dihapus di service auth
+	userEvents := newEventConsumer(app.RDB, app.RedisHealth, stream.Options{
+		Stream:     cfg.UserEventsStream,
+		Group:      cfg.UserEventsGroup,
+		MaxRetries: cfg.UserEventsMaxRetries,
+		RetryIdle:  cfg.UserEventsRetryIdle,
+	})
+	go runUserEventConsumer(context.Background(), userEvents, app.CommentUC)
+
+	// Background job: hapus audit log yang melewati masa retensi
+	go runAuditRetention(context.Background(), app.AuditUC, cfg.AuditRetentionInterval)
+
 	r := gin.New()
 	r.Use(gin.Recovery())
 
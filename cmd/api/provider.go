@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"time"
 
@@ -10,7 +11,10 @@ import (
 	"gorm.io/gorm/logger"
 
 	"khalif-comment/internal/config"
+	"khalif-comment/pkg/auth"
 	"khalif-comment/pkg/database"
+	"khalif-comment/pkg/health"
+	"khalif-comment/pkg/stream"
 
 )
 
@@ -41,8 +45,82 @@ func ProvideDB(cfg *config.Config) *gorm.DB {
 	return db
 }
 
+// ProvideReadDB membuka koneksi ke read replica (DATABASE_READ_URL, biasanya memakai role readonly_user).
+// Jika tidak dikonfigurasi, semua read tetap ke primary
+func ProvideReadDB(cfg *config.Config, primary *gorm.DB) *database.Replica {
+	if cfg.DBReadURL == "" {
+		return database.NewReplica(primary, nil, cfg.ReplicaMaxLag)
+	}
+
+	// Tanpa automatic ping: replica yang mati saat startup tidak boleh menggagalkan aplikasi,
+	// statusnya akan ditangani oleh Monitor
+	replica, err := gorm.Open(postgres.Open(cfg.DBReadURL), &gorm.Config{
+		Logger:               logger.Default.LogMode(logger.Error),
+		PrepareStmt:          true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sqlDB, err := replica.DB()
+	if err != nil {
+		log.Fatal(err)
+	}
+	sqlDB.SetMaxIdleConns(10)
+	sqlDB.SetMaxOpenConns(100)
+	sqlDB.SetConnMaxLifetime(time.Hour)
+
+	r := database.NewReplica(primary, replica, cfg.ReplicaMaxLag)
+	go r.Monitor(context.Background(), cfg.ReplicaCheckInterval)
+	return r
+}
+
+// ProvideJWTVerifier menyiapkan validasi token (HS256 lama dan / atau RS256 / ES256)
+func ProvideJWTVerifier(cfg *config.Config) *auth.Verifier {
+	verifier, err := auth.NewVerifier(auth.Options{
+		HMACSecret:    cfg.JWTSecret,
+		PublicKeyFile: cfg.JWTPublicKeyFile,
+		JWKSFile:      cfg.JWTJWKSFile,
+		Issuer:        cfg.JWTIssuer,
+		Audience:      cfg.JWTAudience,
+		Leeway:        cfg.JWTLeeway,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return verifier
+}
+
+// ProvideRevocations: Daftar token yang dicabut, dibagi semua instance lewat Redis
+func ProvideRevocations(cfg *config.Config, client *redis.Client, monitor *health.RedisMonitor) *auth.Revocations {
+	return auth.NewRevocations(client, monitor, cfg.JWTLeeway, cfg.RevocationUserTTL, cfg.RevocationFailClosed)
+}
+
+// newEventConsumer: Consumer group untuk satu stream event service lain, nil jika nama stream kosong.
+// Tidak lewat Wire karena ada lebih dari satu consumer dengan tipe yang sama
+func newEventConsumer(client *redis.Client, monitor *health.RedisMonitor, opts stream.Options) *stream.Consumer {
+	if opts.Stream == "" {
+		return nil
+	}
+	return stream.NewConsumer(client, monitor, opts)
+}
+
+// ProvideRedis: Timeout dibuat pendek karena Redis hanya cache & rate limit,
+// lebih baik cepat gagal lalu lanjut ke DB daripada request menunggu
 func ProvideRedis(cfg *config.Config) *redis.Client {
 	return redis.NewClient(&redis.Options{
-		Addr: cfg.RedisAddr,
+		Addr:         cfg.RedisAddr,
+		DialTimeout:  cfg.RedisTimeout,
+		ReadTimeout:  cfg.RedisTimeout,
+		WriteTimeout: cfg.RedisTimeout,
 	})
+}
+
+// ProvideRedisMonitor memasang circuit breaker Redis dan menjalankan health check di background.
+// Redis yang mati saat startup tidak menggagalkan aplikasi, service berjalan degraded
+func ProvideRedisMonitor(cfg *config.Config, client *redis.Client) *health.RedisMonitor {
+	m := health.NewRedisMonitor(client, cfg.RedisFailureThreshold)
+	go m.Monitor(context.Background(), cfg.RedisCheckInterval)
+	return m
 }
\ No newline at end of file
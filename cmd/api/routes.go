@@ -1,39 +1,109 @@
 package main
 
 import (
-	"time"
+	"context"
+	"expvar"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.uber.org/zap"
 
 	_ "khalif-comment/docs" // Pastikan folder docs di-generate oleh swag init
 	"khalif-comment/internal/config"
+	"khalif-comment/internal/domain"
+	"khalif-comment/pkg/logger"
 	"khalif-comment/pkg/middleware"
 
 )
 
 func SetupRoutes(r *gin.Engine, app *App, cfg *config.Config) {
-	r.Use(middleware.Logger())
+	r.Use(middleware.RequestID(), middleware.Logger(), middleware.ErrorHandler())
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	// Probe Kubernetes, tanpa rate limit
+	r.GET("/healthz", Liveness)
+	r.GET("/readyz", Readiness(app))
+
+	// Rate Limiter per route group (Redis sliding window, fallback in-memory saat Redis down).
+	// Dipasang setelah middleware auth supaya request yang login dihitung per user
+	rateLimit := func(name, spec string) gin.HandlerFunc {
+		policy, err := middleware.ParseRateLimitPolicy(name, spec)
+		if err != nil {
+			logger.Fatal("Invalid rate limit config", zap.Error(err))
+		}
+		return middleware.RateLimit(app.RDB, app.RedisHealth, policy)
+	}
+	readLimit := rateLimit("read", cfg.RateLimitRead)
+	searchLimit := rateLimit("search", cfg.RateLimitSearch)
+	writeLimit := rateLimit("write", cfg.RateLimitWrite)
+	defaultLimit := rateLimit("default", cfg.RateLimitDefault)
 	
-	// Rate Limiter (Menggunakan Redis dari App)
-	limiter := middleware.RateLimitConfig{Limit: 300, Window: time.Minute}
-	r.Use(middleware.RateLimit(app.RDB, limiter))
-	
-	auth := middleware.AuthMiddleware(cfg.JWTSecret)
+	auth := middleware.AuthMiddleware(app.JWT, app.Revocations)
+	// Token opsional: pemilik & moderator bisa melihat komentar yang tidak published
+	viewer := middleware.OptionalAuth(app.JWT, app.Revocations)
 
 	// --- Public Routes ---
 	// User bisa membaca komentar tanpa harus login
-	r.GET("/api/comments", app.CommentHandler.GetByStory)
+	r.GET("/api/comments", readLimit, app.CommentHandler.GetByStory)
+	r.POST("/api/comments/counts", readLimit, app.CommentHandler.GetCounts) // Batch count untuk feed khalif-stories
+	r.GET("/api/comments/search", viewer, searchLimit, app.CommentHandler.Search)
+	r.GET("/api/comments/:id", viewer, readLimit, app.CommentHandler.GetByID)
+	r.GET("/api/comments/:id/context", viewer, readLimit, app.CommentHandler.GetContext) // Permalink / deep-link notifikasi
+	r.GET("/api/users/:user_id/comments", viewer, readLimit, app.CommentHandler.GetByUser)
 
 	// --- Protected Routes (User Login) ---
 	protected := r.Group("/api")
 	protected.Use(auth)
 	{
 		// Create, Update, Delete butuh data UserID dari token
-		protected.POST("/comments", app.CommentHandler.Create)
-		protected.PUT("/comments/:id", app.CommentHandler.Update)
-		protected.DELETE("/comments/:id", app.CommentHandler.Delete)
+		// Write dibatasi jauh lebih ketat dari read untuk menahan spam
+		protected.POST("/comments", writeLimit, app.CommentHandler.Create)
+		protected.PUT("/comments/:id", writeLimit, app.CommentHandler.Update)
+		protected.DELETE("/comments/:id", defaultLimit, app.CommentHandler.Delete)
+		protected.GET("/users/me/comments", readLimit, app.CommentHandler.GetMyComments)
+		protected.GET("/users/me/export", writeLimit, app.CommentHandler.ExportMyData)     // Berat, dibatasi seperti write
+		protected.DELETE("/users/me/comments", writeLimit, app.CommentHandler.EraseMyData) // Hak hapus data (GDPR)
+	}
+
+	// --- Moderation Routes (Moderator / Admin) ---
+	moderation := r.Group("/api/moderation")
+	moderation.Use(auth, middleware.RequireRole("moderator", "admin"), defaultLimit)
+	{
+		moderation.PATCH("/comments/:id", app.CommentHandler.Moderate)
+		moderation.GET("/comments/:id/duplicates", app.CommentHandler.FindDuplicates) // Deteksi spam copy-paste
+	}
+
+	// --- Admin Routes ---
+	admin := r.Group("/api/admin")
+	admin.Use(auth, middleware.RequireRole("admin"), defaultLimit)
+	{
+		admin.GET("/audit-events", app.AuditHandler.List)
+	}
+
+	// --- Internal Routes (Service-to-service) ---
+	// Autentikasi API key (X-API-Key) per service, dikelola lewat `server apikey`.
+	// Rate limit per IP dipasang sebelum autentikasi agar percobaan key acak ikut terhitung
+	internal := r.Group("/internal")
+	internal.Use(defaultLimit, middleware.APIKeyAuth(authenticateService(app)))
+	{
+		internal.POST("/revocations", middleware.RequireScope(domain.ScopeRevocationsWrite), app.AuthHandler.Revoke) // Logout / ban dari service auth
+		internal.POST("/comments/counts", middleware.RequireScope(domain.ScopeCommentsRead), app.CommentHandler.GetCounts)
+		internal.DELETE("/stories/:story_uuid/comments", middleware.RequireScope(domain.ScopeCommentsDelete), app.CommentHandler.ArchiveStory)
+		internal.POST("/users/:user_id/erasure", middleware.RequireScope(domain.ScopeUsersErase), app.CommentHandler.EraseUserData)
+
+		// Metrics expvar (hit / miss cache per tier, memstats, cmdline), hanya untuk monitoring internal
+		internal.GET("/debug/vars", middleware.RequireScope(domain.ScopeMetricsRead), gin.WrapH(expvar.Handler()))
+	}
+}
+
+// authenticateService: Adapter APIKeyUseCase ke middleware.APIKeyAuth
+func authenticateService(app *App) middleware.ServiceAuthenticator {
+	return func(ctx context.Context, apiKey string) (*middleware.ServiceIdentity, error) {
+		key, err := app.APIKeyUC.Authenticate(ctx, apiKey)
+		if err != nil {
+			return nil, err
+		}
+		return &middleware.ServiceIdentity{Service: key.Service, KeyPrefix: key.Prefix, Scopes: key.Scopes}, nil
 	}
-}
\ No newline at end of file
+}
@@ -19,24 +19,38 @@ func InitializeApp() (*App, error) {
 		// 1. Config & Providers
 		config.LoadConfig,
 		ProvideDB,
+		ProvideReadDB,
 		ProvideRedis,
+		ProvideRedisMonitor,
+		ProvideJWTVerifier,
+		ProvideRevocations,
 
 		// 2. Repositories
 		repository.NewCommentRepository,
 		repository.NewCacheRepository,
+		repository.NewAPIKeyRepository,
+		repository.NewAuditRepository,
 
 		// 3. Bind Interfaces ke Implementasi (Repository)
 		wire.Bind(new(domain.CommentRepository), new(*repository.CommentRepo)),
 		wire.Bind(new(domain.RedisRepository), new(*repository.RedisRepo)),
+		wire.Bind(new(domain.APIKeyRepository), new(*repository.APIKeyRepo)),
+		wire.Bind(new(domain.AuditRepository), new(*repository.AuditRepo)),
 
 		// 4. UseCases
 		usecase.NewCommentUseCase,
+		usecase.NewAPIKeyUseCase,
+		usecase.NewAuditUseCase,
 
 		// 5. Bind Interfaces ke Implementasi (UseCase)
 		wire.Bind(new(domain.CommentUseCase), new(*usecase.CommentUC)),
+		wire.Bind(new(domain.APIKeyUseCase), new(*usecase.APIKeyUC)),
+		wire.Bind(new(domain.AuditUseCase), new(*usecase.AuditUC)),
 
 		// 6. Handlers
 		handler.NewCommentHandler,
+		handler.NewAuthHandler,
+		handler.NewAuditHandler,
 
 		// 7. App Entry Point
 		NewApp,
@@ -22,11 +22,21 @@ import (
 func InitializeApp() (*App, error) {
 	configConfig := config.LoadConfig()
 	db := ProvideDB(configConfig)
+	replica := ProvideReadDB(configConfig, db)
 	client := ProvideRedis(configConfig)
-	commentRepo := repository.NewCommentRepository(db)
-	redisRepo := repository.NewCacheRepository(client)
-	commentUC := usecase.NewCommentUseCase(commentRepo, redisRepo)
+	redisMonitor := ProvideRedisMonitor(configConfig, client)
+	verifier := ProvideJWTVerifier(configConfig)
+	revocations := ProvideRevocations(configConfig, client, redisMonitor)
+	commentRepo := repository.NewCommentRepository(db, replica)
+	redisRepo := repository.NewCacheRepository(client, redisMonitor)
+	auditRepo := repository.NewAuditRepository(db)
+	commentUC := usecase.NewCommentUseCase(commentRepo, redisRepo, auditRepo, configConfig)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	apiKeyUC := usecase.NewAPIKeyUseCase(apiKeyRepo, redisRepo)
+	auditUC := usecase.NewAuditUseCase(auditRepo, configConfig)
 	commentHandler := handler.NewCommentHandler(commentUC)
-	app := NewApp(db, client, commentHandler)
+	authHandler := handler.NewAuthHandler(revocations)
+	auditHandler := handler.NewAuditHandler(auditUC)
+	app := NewApp(db, replica, client, redisMonitor, verifier, revocations, commentUC, apiKeyUC, auditUC, commentHandler, authHandler, auditHandler)
 	return app, nil
 }
@@ -23,9 +23,111 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/audit-events": {
+            "get": {
+                "description": "Append-only record of comment create/update/delete/moderation, story archiving and user erasure, newest first. Includes who acted (user, service or system), when, from which IP and request, plus before/after snapshots. Comment snapshots never contain the comment text or author user_id (only content_length and content_sha256), so erasing a user leaves nothing of theirs behind in this log. Admin only",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List audit events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID or service name",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "e.g. comment.moderate",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comment ID, story ID or user ID",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Story UUID",
+                        "name": "story_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339, inclusive",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339, exclusive",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from previous page (meta.next_cursor)",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.AuditEvent"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
         "/comments": {
             "get": {
-                "description": "Retrieve all comments for a specific story",
+                "description": "Retrieve a page of comments for a specific story. Supports conditional GET via ETag / Last-Modified",
                 "produces": [
                     "application/json"
                 ],
@@ -40,6 +142,36 @@ const docTemplate = `{
                         "name": "story_id",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from previous page (meta.next_cursor)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "newest (default) or oldest",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from previous response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified from previous response",
+                        "name": "If-Modified-Since",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -52,6 +184,9 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "304": {
+                        "description": "Not Modified"
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
@@ -61,12 +196,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Create a new comment for a story",
+                "description": "Create a new comment for a story. Content supports a Markdown subset (**bold**, *italic*, ` + "`" + `code` + "`" + `, [links](https://...), ||spoiler||, \u003e quote) and is returned both as source (content) and sanitized HTML (content_html). Content is normalized first (NFC, trimmed, invisible characters removed, excessive blank lines collapsed) and its length is counted in grapheme clusters; violations return 400 with per-field errors in fields",
                 "consumes": [
                     "application/json"
                 ],
@@ -107,17 +237,186 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.APIResponse"
                         }
                     }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/comments/counts": {
+            "post": {
+                "description": "Batch lookup of published comment counts, keyed by story UUID (max 100 stories per request)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get comment counts for many stories",
+                "parameters": [
+                    {
+                        "description": "Story UUIDs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.CommentCountsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer",
+                                "format": "int64"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/comments/search": {
+            "get": {
+                "description": "Full-text search over comments (Indonesian stemming), ranked by relevance with highlighted snippets. Fuzzy mode uses trigram similarity and returns similarity scores",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Search comments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query (websearch syntax: quoted phrases, OR, -exclusion)",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "fulltext (default) or fuzzy (trigram, typo tolerant)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by story UUID",
+                        "name": "story_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by user ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (default 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.SearchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
                 }
             }
         },
         "/comments/{id}": {
-            "put": {
-                "security": [
+            "get": {
+                "description": "Retrieve a single comment by ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get a comment",
+                "parameters": [
                     {
-                        "BearerAuth": []
+                        "type": "string",
+                        "description": "Comment ID (ULID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
-                "description": "Update comment content (Owner only)",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update comment content (Owner only). content_html is re-rendered from the new Markdown source. Content is normalized and length-checked the same way as on create",
                 "consumes": [
                     "application/json"
                 ],
@@ -130,8 +429,8 @@ const docTemplate = `{
                 "summary": "Update a comment",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Comment ID",
+                        "type": "string",
+                        "description": "Comment ID (ULID)",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -177,14 +476,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.APIResponse"
                         }
                     }
-                }
-            },
-            "delete": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
+                ]
+            },
+            "delete": {
                 "description": "Delete a comment by ID (Owner only)",
                 "produces": [
                     "application/json"
@@ -195,8 +494,8 @@ const docTemplate = `{
                 "summary": "Delete a comment",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Comment ID",
+                        "type": "string",
+                        "description": "Comment ID (ULID)",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -209,6 +508,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.APIResponse"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
                     "403": {
                         "description": "Unauthorized Action",
                         "schema": {
@@ -227,22 +532,777 @@ const docTemplate = `{
                             "$ref": "#/definitions/utils.APIResponse"
                         }
                     }
-                }
-            }
-        }
-    },
-    "definitions": {
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/comments/{id}/context": {
+            "get": {
+                "description": "Retrieve a comment with its surrounding comments and the page cursor of the story list where it appears.\nComments are flat (no replies, no parent_id), so there are no ancestors to return: the context is the neighbouring comments in the story list only",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get comment permalink context",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comment ID (ULID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of surrounding comments on each side (default 3, max 20)",
+                        "name": "siblings",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size used by the client for the story list (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.CommentContext"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Always 200 while the process is running",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/internal/revocations": {
+            "post": {
+                "description": "Called by the auth service on logout or ban. Revokes a single token by jti and/or every token of a user issued at or before issued_before",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Revoke tokens",
+                "parameters": [
+                    {
+                        "description": "Revocation",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.RevocationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/internal/stories/{story_uuid}/comments": {
+            "delete": {
+                "description": "Called by khalif-stories when a story is removed. Moves every comment of the story to the archive in batches, clears its caches and emits one story.comments_archived event. Idempotent",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Archive all comments of a deleted story",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Story UUID",
+                        "name": "story_uuid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.StoryArchiveResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/internal/users/{user_id}/erasure": {
+            "post": {
+                "description": "Called by the auth service when an account is deleted. Anonymizes or deletes every comment of the user according to the erasure policy. Idempotent",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "internal"
+                ],
+                "summary": "Erase a user's comments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.UserErasureResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ]
+            }
+        },
+        "/moderation/comments/{id}": {
+            "patch": {
+                "description": "Change the moderation status of a comment: published, pending, hidden or shadow_banned (Moderator only)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Moderate a comment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comment ID (ULID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New Status",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handler.ModerateCommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Comment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/moderation/comments/{id}/duplicates": {
+            "get": {
+                "description": "Find comments across all stories whose content is similar to the given comment (trigram similarity), to spot copy-paste spam (Moderator only)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "moderation"
+                ],
+                "summary": "Find near-duplicate comments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comment ID (ULID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum similarity 0..1 (default 0.6)",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Max results (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.SearchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "503 only when the primary database is unreachable. Redis or replica outages are reported as degraded with status 200",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/main.ReadinessStatus"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/comments": {
+            "get": {
+                "description": "Retrieve a page of the current user's comments, including pending, hidden and shadow-banned ones",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get my comments",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from previous page (meta.next_cursor)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "newest (default) or oldest",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Comment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Anonymize or permanently delete every comment of the current user, depending on the server erasure policy. Cannot be undone",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Erase my comments",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.UserErasureResult"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/users/me/export": {
+            "get": {
+                "description": "Download a JSON archive of every comment written by the current user (all statuses, including comments archived because their story was deleted). This service stores comments only, there are no revisions, reactions or reports to export.\nThe archive is streamed synchronously in this request instead of being prepared by a background job: it is built in batches with constant memory, so there is nothing to poll or download later. Errors before the first byte return 500; an error mid-stream truncates the body, which then is not valid JSON and must be retried",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Export my data",
+                "responses": {
+                    "200": {
+                        "description": "Streamed archive: user_id, generated_at, comments[], archived_comments[]",
+                        "schema": {
+                            "$ref": "#/definitions/domain.UserExport"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/users/{user_id}/comments": {
+            "get": {
+                "description": "Retrieve a page of comments written by a user (profile page). Pending, hidden and shadow-banned comments are only included for the owner or a moderator",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "comments"
+                ],
+                "summary": "Get comments by user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from previous page (meta.next_cursor)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "newest (default) or oldest",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Comment"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/utils.APIResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "domain.AuditEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor_id": {
+                    "type": "string"
+                },
+                "actor_role": {
+                    "type": "string"
+                },
+                "actor_type": {
+                    "description": "user / service / system",
+                    "type": "string"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "story_id": {
+                    "type": "string"
+                },
+                "target_id": {
+                    "type": "string"
+                },
+                "target_type": {
+                    "type": "string"
+                }
+            }
+        },
         "domain.Comment": {
             "type": "object",
             "properties": {
                 "content": {
+                    "description": "Source Markdown dari user",
+                    "type": "string"
+                },
+                "content_html": {
+                    "description": "Hasil pkg/markdown.Render, aman ditampilkan langsung",
                     "type": "string"
                 },
                 "created_at": {
                     "type": "string"
                 },
                 "id": {
+                    "description": "ULID yang diekspos ke API",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "story_id": {
+                    "description": "UUID Story dari service khalif-stories",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "description": "User ID dari JWT",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.CommentContext": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "$ref": "#/definitions/domain.Comment"
+                },
+                "newer": {
+                    "description": "N komentar tepat di atasnya (urutan list)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Comment"
+                    }
+                },
+                "older": {
+                    "description": "N komentar tepat di bawahnya",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Comment"
+                    }
+                },
+                "page_cursor": {
+                    "description": "Cursor halaman yang memuat komentar ini, kosong = halaman pertama",
+                    "type": "string"
+                },
+                "position": {
+                    "description": "Index 0-based di list story (terbaru di atas)",
                     "type": "integer"
+                }
+            }
+        },
+        "domain.SearchResult": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "Source Markdown dari user",
+                    "type": "string"
+                },
+                "content_html": {
+                    "description": "Hasil pkg/markdown.Render, aman ditampilkan langsung",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ULID yang diekspos ke API",
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "similarity": {
+                    "description": "Skor trigram 0..1 (mode fuzzy \u0026 duplikat)",
+                    "type": "number"
+                },
+                "snippet": {
+                    "description": "HTML-escaped, kata yang cocok dibungkus \u003cmark\u003e",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
                 },
                 "story_id": {
                     "description": "UUID Story dari service khalif-stories",
@@ -257,6 +1317,68 @@ const docTemplate = `{
                 }
             }
         },
+        "domain.StoryArchiveResult": {
+            "type": "object",
+            "properties": {
+                "archived": {
+                    "description": "0 jika story sudah diarsipkan sebelumnya",
+                    "type": "integer"
+                },
+                "story_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.UserErasureResult": {
+            "type": "object",
+            "properties": {
+                "archived_comments": {
+                    "description": "Termasuk arsip (story yang sudah dihapus)",
+                    "type": "integer"
+                },
+                "comments": {
+                    "description": "Komentar aktif yang dianonimkan / dihapus",
+                    "type": "integer"
+                },
+                "policy": {
+                    "type": "string"
+                },
+                "stories": {
+                    "description": "Jumlah story yang cache-nya di-invalidate",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.UserExport": {
+            "type": "object",
+            "properties": {
+                "generated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handler.CommentCountsRequest": {
+            "type": "object",
+            "required": [
+                "story_ids"
+            ],
+            "properties": {
+                "story_ids": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "handler.CreateCommentRequest": {
             "type": "object",
             "required": [
@@ -272,6 +1394,39 @@ const docTemplate = `{
                 }
             }
         },
+        "handler.ModerateCommentRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "example": "hidden"
+                }
+            }
+        },
+        "handler.RevocationRequest": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "exp token, kosong = memakai REVOCATION_USER_TTL",
+                    "type": "string"
+                },
+                "issued_before": {
+                    "description": "Kosong = sekarang",
+                    "type": "string"
+                },
+                "jti": {
+                    "type": "string",
+                    "example": "01J9Z3K4T5V6W7X8Y9Z0ABCDEF"
+                },
+                "user_id": {
+                    "type": "string",
+                    "example": "42"
+                }
+            }
+        },
         "handler.UpdateCommentRequest": {
             "type": "object",
             "required": [
@@ -283,6 +1438,27 @@ const docTemplate = `{
                 }
             }
         },
+        "main.ReadinessStatus": {
+            "type": "object",
+            "properties": {
+                "database": {
+                    "type": "string",
+                    "example": "up"
+                },
+                "redis": {
+                    "type": "string",
+                    "example": "down"
+                },
+                "replica": {
+                    "type": "string",
+                    "example": "disabled"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "degraded"
+                }
+            }
+        },
         "utils.APIResponse": {
             "type": "object",
             "properties": {
@@ -290,14 +1466,48 @@ const docTemplate = `{
                 "error": {
                     "type": "string"
                 },
+                "error_code": {
+                    "description": "Stabil, dipakai client untuk membedakan error",
+                    "type": "string",
+                    "example": "COMMENT_NOT_FOUND"
+                },
+                "fields": {
+                    "description": "Error per field untuk body yang gagal validasi",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/utils.FieldError"
+                    }
+                },
                 "message": {
                     "type": "string"
                 },
                 "meta": {}
             }
+        },
+        "utils.FieldError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "required"
+                },
+                "field": {
+                    "type": "string",
+                    "example": "content"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "content wajib diisi"
+                }
+            }
         }
     },
     "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
         "BearerAuth": {
             "type": "apiKey",
             "name": "Authorization",
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/spf13/viper"
 
@@ -12,11 +13,140 @@ type Config struct {
 	DBUrl     string `mapstructure:"DATABASE_URL"`
 	RedisAddr string `mapstructure:"REDIS_ADDR"`
 	Port      string `mapstructure:"PORT"`
-	JWTSecret string `mapstructure:"JWT_SECRET"` // Wajib ada untuk Auth User
+	AppEnv    string `mapstructure:"APP_ENV"`    // production / staging / development / local / test
+	JWTSecret string `mapstructure:"JWT_SECRET"` // HS256. Wajib ada jika JWT_PUBLIC_KEY_FILE / JWT_JWKS_FILE kosong
+
+	// Validasi token: RS256 / ES256 dari file PEM atau JWKS lokal (dibaca ulang saat file berubah)
+	JWTPublicKeyFile string        `mapstructure:"JWT_PUBLIC_KEY_FILE"`
+	JWTJWKSFile      string        `mapstructure:"JWT_JWKS_FILE"`
+	JWTIssuer        string        `mapstructure:"JWT_ISSUER"`
+	JWTAudience      string        `mapstructure:"JWT_AUDIENCE"`
+	JWTLeeway        time.Duration `mapstructure:"JWT_LEEWAY"`
+
+	// Revocation token dari service auth (logout / ban). USER_TTL harus >= umur token terpanjang.
+	// FAIL_CLOSED: tolak request login (503) saat Redis down, default fail-open
+	RevocationUserTTL    time.Duration `mapstructure:"REVOCATION_USER_TTL"`
+	RevocationFailClosed bool          `mapstructure:"REVOCATION_FAIL_CLOSED"`
+
+	// Terima ID numerik lama di path API selama masa migrasi ke ULID
+	AllowLegacyIDs bool `mapstructure:"ALLOW_LEGACY_IDS"`
+
+	// Interval job rekonsiliasi tabel story_comment_stats
+	StatsReconcileInterval time.Duration `mapstructure:"STATS_RECONCILE_INTERVAL"`
+
+	// Jalankan migrasi yang belum diterapkan saat server start (matikan jika migrasi dijalankan terpisah)
+	MigrateOnStart bool `mapstructure:"MIGRATE_ON_START"`
+
+	// Read replica (opsional). List, count & search diarahkan ke sini selama lag di bawah batas
+	DBReadURL            string        `mapstructure:"DATABASE_READ_URL"`
+	ReplicaMaxLag        time.Duration `mapstructure:"REPLICA_MAX_LAG"`
+	ReplicaCheckInterval time.Duration `mapstructure:"REPLICA_CHECK_INTERVAL"`
+
+	// Stale-while-revalidate list komentar: setelah invalidasi, list lama tetap disajikan
+	// (maksimal CACHE_STALE_TTL) sementara satu worker membangun ulang dari DB
+	CacheStaleWhileRevalidate bool          `mapstructure:"CACHE_STALE_WHILE_REVALIDATE"`
+	CacheStaleTTL             time.Duration `mapstructure:"CACHE_STALE_TTL"`
+
+	// L1 cache in-process di depan Redis. Size 0 = dimatikan
+	CacheLocalSize int           `mapstructure:"CACHE_LOCAL_SIZE"`
+	CacheLocalTTL  time.Duration `mapstructure:"CACHE_LOCAL_TTL"`
+
+	// Circuit breaker Redis: dibuka setelah N error berturut-turut, ditutup lagi oleh health check yang berhasil
+	RedisTimeout          time.Duration `mapstructure:"REDIS_TIMEOUT"`
+	RedisFailureThreshold int           `mapstructure:"REDIS_FAILURE_THRESHOLD"`
+	RedisCheckInterval    time.Duration `mapstructure:"REDIS_CHECK_INTERVAL"`
+
+	// Rate limit per route group, format "<limit>/<window>" (contoh "300/1m").
+	// Dihitung per user jika login, per IP jika anonim
+	RateLimitRead    string `mapstructure:"RATE_LIMIT_READ"`    // List & detail komentar
+	RateLimitSearch  string `mapstructure:"RATE_LIMIT_SEARCH"`  // Pencarian (query DB lebih berat)
+	RateLimitWrite   string `mapstructure:"RATE_LIMIT_WRITE"`   // Create & update komentar
+	RateLimitDefault string `mapstructure:"RATE_LIMIT_DEFAULT"` // Route lain (delete, moderasi, dst)
+
+	// Event lifecycle story dari khalif-stories (Redis Stream, field "type" & "story_uuid").
+	// Stream kosong = consumer dimatikan. Pesan gagal diulang setelah RETRY_IDLE, lalu ke <stream>:dead
+	StoryEventsStream     string        `mapstructure:"STORY_EVENTS_STREAM"`
+	StoryEventsGroup      string        `mapstructure:"STORY_EVENTS_GROUP"`
+	StoryEventsMaxRetries int64         `mapstructure:"STORY_EVENTS_MAX_RETRIES"`
+	StoryEventsRetryIdle  time.Duration `mapstructure:"STORY_EVENTS_RETRY_IDLE"`
+
+	// Event akun dari service auth (field "type" = "user.deleted" & "user_id"), memicu penghapusan data user.
+	// Stream kosong = consumer dimatikan. Retry & dead-letter bekerja sama seperti event story
+	UserEventsStream     string        `mapstructure:"USER_EVENTS_STREAM"`
+	UserEventsGroup      string        `mapstructure:"USER_EVENTS_GROUP"`
+	UserEventsMaxRetries int64         `mapstructure:"USER_EVENTS_MAX_RETRIES"`
+	UserEventsRetryIdle  time.Duration `mapstructure:"USER_EVENTS_RETRY_IDLE"`
+
+	// Stream event keluar service ini (story.comments_archived, dst). Kosong = tidak mengirim event
+	CommentEventsStream string `mapstructure:"COMMENT_EVENTS_STREAM"`
+
+	// Penghapusan data user: "anonymize" (komentar tetap, identitas dilepas) atau "delete" (hapus permanen)
+	ErasurePolicy string `mapstructure:"ERASURE_POLICY"`
+
+	// Audit log: event lebih tua dari AUDIT_RETENTION dihapus tiap AUDIT_RETENTION_INTERVAL. 0 = simpan selamanya
+	AuditRetention         time.Duration `mapstructure:"AUDIT_RETENTION"`
+	AuditRetentionInterval time.Duration `mapstructure:"AUDIT_RETENTION_INTERVAL"`
+
+	// Bahasa pesan API (id / en) untuk request tanpa Accept-Language yang didukung
+	DefaultLanguage string `mapstructure:"DEFAULT_LANGUAGE"`
+
+	// Normalisasi isi komentar sebelum disimpan, lihat pkg/textnorm.
+	// Panjang dihitung dalam grapheme cluster (emoji gabungan = 1), CONTENT_MAX_LENGTH 0 = tanpa batas
+	ContentNFC            bool `mapstructure:"CONTENT_NFC"`
+	ContentStripInvisible bool `mapstructure:"CONTENT_STRIP_INVISIBLE"`
+	ContentTrim           bool `mapstructure:"CONTENT_TRIM"`
+	ContentMaxNewlines    int  `mapstructure:"CONTENT_MAX_NEWLINES"` // Maksimal newline berturut-turut (2 = satu baris kosong), 0 = tidak dibatasi
+	ContentMinLength      int  `mapstructure:"CONTENT_MIN_LENGTH"`
+	ContentMaxLength      int  `mapstructure:"CONTENT_MAX_LENGTH"`
 }
 
 func LoadConfig() *Config {
 	viper.AutomaticEnv()
+	// Default paling aman: dianggap production sampai dinyatakan lain
+	viper.SetDefault("APP_ENV", "production")
+	viper.SetDefault("JWT_PUBLIC_KEY_FILE", "")
+	viper.SetDefault("JWT_JWKS_FILE", "")
+	viper.SetDefault("JWT_ISSUER", "")
+	viper.SetDefault("JWT_AUDIENCE", "")
+	viper.SetDefault("JWT_LEEWAY", "30s")
+	viper.SetDefault("REVOCATION_USER_TTL", "720h")
+	viper.SetDefault("REVOCATION_FAIL_CLOSED", false)
+	viper.SetDefault("ALLOW_LEGACY_IDS", true)
+	viper.SetDefault("STATS_RECONCILE_INTERVAL", "10m")
+	viper.SetDefault("MIGRATE_ON_START", true)
+	viper.SetDefault("DATABASE_READ_URL", "")
+	viper.SetDefault("REPLICA_MAX_LAG", "5s")
+	viper.SetDefault("REPLICA_CHECK_INTERVAL", "5s")
+	viper.SetDefault("CACHE_STALE_WHILE_REVALIDATE", false)
+	viper.SetDefault("CACHE_STALE_TTL", "1m")
+	viper.SetDefault("CACHE_LOCAL_SIZE", 10000)
+	viper.SetDefault("CACHE_LOCAL_TTL", "10s")
+	viper.SetDefault("REDIS_TIMEOUT", "500ms")
+	viper.SetDefault("REDIS_FAILURE_THRESHOLD", 3)
+	viper.SetDefault("REDIS_CHECK_INTERVAL", "2s")
+	viper.SetDefault("RATE_LIMIT_READ", "300/1m")
+	viper.SetDefault("RATE_LIMIT_SEARCH", "60/1m")
+	viper.SetDefault("RATE_LIMIT_WRITE", "10/1m")
+	viper.SetDefault("RATE_LIMIT_DEFAULT", "120/1m")
+	viper.SetDefault("STORY_EVENTS_STREAM", "story_events")
+	viper.SetDefault("STORY_EVENTS_GROUP", "khalif-comment")
+	viper.SetDefault("STORY_EVENTS_MAX_RETRIES", 5)
+	viper.SetDefault("STORY_EVENTS_RETRY_IDLE", "30s")
+	viper.SetDefault("USER_EVENTS_STREAM", "user_events")
+	viper.SetDefault("USER_EVENTS_GROUP", "khalif-comment")
+	viper.SetDefault("USER_EVENTS_MAX_RETRIES", 5)
+	viper.SetDefault("USER_EVENTS_RETRY_IDLE", "30s")
+	viper.SetDefault("COMMENT_EVENTS_STREAM", "comment_events")
+	viper.SetDefault("ERASURE_POLICY", "anonymize")
+	viper.SetDefault("AUDIT_RETENTION", "8760h")
+	viper.SetDefault("AUDIT_RETENTION_INTERVAL", "24h")
+	viper.SetDefault("DEFAULT_LANGUAGE", "id")
+	viper.SetDefault("CONTENT_NFC", true)
+	viper.SetDefault("CONTENT_STRIP_INVISIBLE", true)
+	viper.SetDefault("CONTENT_TRIM", true)
+	viper.SetDefault("CONTENT_MAX_NEWLINES", 2)
+	viper.SetDefault("CONTENT_MIN_LENGTH", 1)
+	viper.SetDefault("CONTENT_MAX_LENGTH", 5000)
 	viper.SetConfigName(".env")
 	viper.SetConfigType("env")
 
@@ -52,9 +182,24 @@ func LoadConfig() *Config {
 	if config.DBUrl == "" {
 		log.Fatal("FATAL: DATABASE_URL is empty. Please check your docker-compose.yml or .env")
 	}
-	if config.JWTSecret == "" {
-		log.Fatal("FATAL: JWT_SECRET is empty. Auth cannot work without it.")
+	if config.JWTSecret == "" && config.JWTPublicKeyFile == "" && config.JWTJWKSFile == "" {
+		log.Fatal("FATAL: JWT_SECRET, JWT_PUBLIC_KEY_FILE and JWT_JWKS_FILE are all empty. Auth cannot work without a key.")
+	}
+	if config.ErasurePolicy != "anonymize" && config.ErasurePolicy != "delete" {
+		log.Fatalf("FATAL: ERASURE_POLICY must be anonymize or delete, got %q", config.ErasurePolicy)
+	}
+	if config.ContentMaxLength > 0 && config.ContentMaxLength < config.ContentMinLength {
+		log.Fatalf("FATAL: CONTENT_MAX_LENGTH (%d) is smaller than CONTENT_MIN_LENGTH (%d)", config.ContentMaxLength, config.ContentMinLength)
 	}
 
 	return &config
-}
\ No newline at end of file
+}
+
+// IsNonProduction: Hanya environment ini yang boleh menjalankan operasi destruktif seperti reset
+func (c *Config) IsNonProduction() bool {
+	switch c.AppEnv {
+	case "development", "local", "test":
+		return true
+	}
+	return false
+}
@@ -2,19 +2,276 @@ package domain
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"time"
 
+	"khalif-comment/pkg/pagination"
+
 )
 
 // --- ENTITIES ---
 
+// Tag gorm di bawah hanya dokumentasi, schema dikelola oleh migrasi SQL di pkg/database/schema
+
 type Comment struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	StoryUUID string    `gorm:"index;not null" json:"story_id"` // UUID Story dari service khalif-stories
-	UserID    string    `gorm:"index;not null" json:"user_id"`  // User ID dari JWT
-	Content   string    `gorm:"type:text;not null" json:"content"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID          uint      `gorm:"primaryKey" json:"-"`                               // Internal, hanya untuk join
+	PublicID    string    `gorm:"size:26;uniqueIndex;not null" json:"id"`            // ULID yang diekspos ke API
+	StoryUUID   string    `gorm:"index;not null" json:"story_id"`                    // UUID Story dari service khalif-stories
+	UserID      string    `gorm:"index;not null" json:"user_id"`                     // User ID dari JWT
+	Content     string    `gorm:"type:text;not null" json:"content"`                 // Source Markdown dari user
+	ContentHTML string    `gorm:"type:text;not null;default:''" json:"content_html"` // Hasil pkg/markdown.Render, aman ditampilkan langsung
+	Status      string    `gorm:"size:20;not null;default:published;index" json:"status"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Status moderasi komentar. Hanya "published" yang tampil ke publik,
+// status lain hanya terlihat oleh pemilik komentar & moderator
+const (
+	CommentStatusPublished    = "published"
+	CommentStatusPending      = "pending"
+	CommentStatusHidden       = "hidden"
+	CommentStatusShadowBanned = "shadow_banned"
+)
+
+// IsValidCommentStatus: Validasi input status dari moderator
+func IsValidCommentStatus(status string) bool {
+	switch status {
+	case CommentStatusPublished, CommentStatusPending, CommentStatusHidden, CommentStatusShadowBanned:
+		return true
+	}
+	return false
+}
+
+// Urutan list komentar
+const (
+	SortNewest = "newest" // default
+	SortOldest = "oldest"
+)
+
+// CommentQuery: Parameter pagination list komentar (keyset / cursor based)
+type CommentQuery struct {
+	Limit            int
+	After            *pagination.Cursor // nil = halaman pertama
+	Sort             string             // SortNewest / SortOldest
+	IncludeNonPublic bool               // true = semua status (pemilik / moderator)
+	Fresh            bool               // true = baca dari primary, untuk hasil yang akan di-cache
+}
+
+// Viewer: Identitas yang sedang melihat data (kosong = anonim)
+type Viewer struct {
+	UserID string
+	Role   string
+}
+
+// IsModerator: Moderator & admin boleh melihat komentar yang tidak published
+func (v Viewer) IsModerator() bool {
+	return v.Role == "moderator" || v.Role == "admin"
+}
+
+// CanSee: Komentar non-published hanya terlihat oleh pemiliknya & moderator
+func (v Viewer) CanSee(c *Comment) bool {
+	if c.Status == CommentStatusPublished {
+		return true
+	}
+	return (v.UserID != "" && v.UserID == c.UserID) || v.IsModerator()
+}
+
+// CommentPage: Satu halaman komentar beserta cursor halaman berikutnya
+type CommentPage struct {
+	Items      []Comment `json:"items"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Stale      bool      `json:"-"` // Disajikan dari cache basi sambil di-refresh (stale-while-revalidate)
+}
+
+// CommentContext: Komentar beserta tetangganya di story, untuk permalink / deep-link notifikasi.
+// Tidak ada ancestors karena komentar tidak bertingkat (tanpa parent)
+type CommentContext struct {
+	Comment    Comment   `json:"comment"`
+	Newer      []Comment `json:"newer"`                 // N komentar tepat di atasnya (urutan list)
+	Older      []Comment `json:"older"`                 // N komentar tepat di bawahnya
+	Position   int64     `json:"position"`              // Index 0-based di list story (terbaru di atas)
+	PageCursor string    `json:"page_cursor,omitempty"` // Cursor halaman yang memuat komentar ini, kosong = halaman pertama
+}
+
+// Mode pencarian komentar
+const (
+	SearchModeFullText = "fulltext" // default, tsvector + stemming
+	SearchModeFuzzy    = "fuzzy"    // pg_trgm, tahan typo & slang
+)
+
+// SearchQuery: Parameter pencarian komentar
+type SearchQuery struct {
+	Query            string
+	Mode             string // SearchModeFullText / SearchModeFuzzy
+	StoryUUID        string // Opsional
+	UserID           string // Opsional
+	Limit            int
+	Page             int  // 1-based, hasil diurutkan berdasarkan relevansi
+	IncludeNonPublic bool // true = moderator, ikut cari komentar yang tidak published
+}
+
+// SearchResult: Komentar hasil pencarian beserta skor relevansi & potongan teks yang di-highlight
+type SearchResult struct {
+	Comment
+	Rank       float64 `json:"rank"`
+	Similarity float64 `json:"similarity,omitempty"` // Skor trigram 0..1 (mode fuzzy & duplikat)
+	Snippet    string  `json:"snippet,omitempty"`    // HTML-escaped, kata yang cocok dibungkus <mark>
+}
+
+// SearchPage: Satu halaman hasil pencarian
+type SearchPage struct {
+	Items   []SearchResult `json:"items"`
+	HasMore bool           `json:"has_more"`
+}
+
+// StoryStats: Ringkasan komentar per story, dasar perhitungan ETag & Last-Modified
+type StoryStats struct {
+	Count       int64     `json:"count"`
+	LastUpdated time.Time `json:"last_updated"` // Perubahan terakhir (monoton, termasuk hapus / sembunyikan), zero = belum ada
+}
+
+// APIKey: Kredensial service-to-service untuk endpoint /internal.
+// Format key plaintext: "kck_<prefix>_<secret>", yang disimpan hanya sha256-nya
+type APIKey struct {
+	ID         uint       `json:"-"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Service    string     `json:"service"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Scope API key. Satu key hanya boleh memanggil endpoint sesuai scope-nya
+const (
+	ScopeCommentsRead     = "comments:read"     // Batch count, dst
+	ScopeCommentsDelete   = "comments:delete"   // Bulk delete saat story dihapus
+	ScopeRevocationsWrite = "revocations:write" // Push logout / ban dari service auth
+	ScopeUsersErase       = "users:erase"       // Penghapusan data user (akun dihapus di service auth)
+	ScopeMetricsRead      = "metrics:read"      // Metrics expvar untuk monitoring internal
+)
+
+// IsValidScope: Validasi scope saat membuat key
+func IsValidScope(scope string) bool {
+	switch scope {
+	case ScopeCommentsRead, ScopeCommentsDelete, ScopeRevocationsWrite, ScopeUsersErase, ScopeMetricsRead:
+		return true
+	}
+	return false
+}
+
+// Alasan pengarsipan komentar (kolom comments_archive.archive_reason)
+const (
+	ArchiveReasonStoryDeleted = "story_deleted"
+)
+
+// ArchivedComment: Komentar di tabel comments_archive
+type ArchivedComment struct {
+	Comment       `gorm:"embedded"`
+	ArchivedAt    time.Time `json:"archived_at"`
+	ArchiveReason string    `json:"archive_reason"`
+}
+
+// Kebijakan penghapusan data user (ERASURE_POLICY)
+const (
+	ErasurePolicyAnonymize = "anonymize" // Komentar tetap ada, user_id diganti ErasedUserID
+	ErasurePolicyDelete    = "delete"    // Komentar dihapus permanen
+)
+
+// ErasedUserID: Pengganti user_id pada komentar yang dianonimkan, tidak mungkin dipakai JWT sungguhan
+const ErasedUserID = "[deleted]"
+
+// IsValidErasurePolicy: Validasi config ERASURE_POLICY
+func IsValidErasurePolicy(policy string) bool {
+	return policy == ErasurePolicyAnonymize || policy == ErasurePolicyDelete
+}
+
+// UserErasureResult: Hasil penghapusan data satu user
+type UserErasureResult struct {
+	UserID           string `json:"user_id"`
+	Policy           string `json:"policy"`
+	Comments         int64  `json:"comments"`          // Komentar aktif yang dianonimkan / dihapus
+	ArchivedComments int64  `json:"archived_comments"` // Termasuk arsip (story yang sudah dihapus)
+	Stories          int    `json:"stories"`           // Jumlah story yang cache-nya di-invalidate
+}
+
+// UserExport: Header arsip data user. Daftar komentar ditulis bertahap (streaming), bukan lewat struct ini
+type UserExport struct {
+	UserID      string    `json:"user_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// StoryArchiveResult: Hasil pengarsipan komentar satu story
+type StoryArchiveResult struct {
+	StoryUUID string `json:"story_id"`
+	Archived  int64  `json:"archived"` // 0 jika story sudah diarsipkan sebelumnya
+}
+
+// AuditCommentSnapshot: Isi before/after audit untuk komentar. Isi & user_id tidak disimpan agar
+// erasure user tidak tertinggal di audit log yang append-only, cukup panjang (rune) & sha256 isinya
+type AuditCommentSnapshot struct {
+	ID            string    `json:"id"`
+	StoryUUID     string    `json:"story_id"`
+	Status        string    `json:"status"`
+	ContentLength int       `json:"content_length"`
+	ContentSHA256 string    `json:"content_sha256"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// AuditEvent: Satu baris audit_events (append-only)
+type AuditEvent struct {
+	ID         uint            `json:"id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	ActorType  string          `json:"actor_type"` // user / service / system
+	ActorID    string          `json:"actor_id"`
+	ActorRole  string          `json:"actor_role,omitempty"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	StoryUUID  string          `json:"story_id,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After      json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	IP         string          `json:"ip,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+}
+
+// Aksi yang dicatat di audit log
+const (
+	AuditCommentCreate   = "comment.create"
+	AuditCommentUpdate   = "comment.update"
+	AuditCommentDelete   = "comment.delete"
+	AuditCommentModerate = "comment.moderate"
+	AuditStoryArchive    = "story.archive"
+	AuditUserErase       = "user.erase"
+)
+
+// Jenis target audit
+const (
+	AuditTargetComment = "comment"
+	AuditTargetStory   = "story"
+	AuditTargetUser    = "user"
+)
+
+// AuditQuery: Filter endpoint admin, semua opsional. Diurutkan terbaru di atas (keyset berdasarkan id)
+type AuditQuery struct {
+	ActorID   string
+	Action    string
+	TargetID  string
+	StoryUUID string
+	From      *time.Time
+	To        *time.Time
+	BeforeID  uint // Cursor: hanya event dengan id < BeforeID, 0 = halaman pertama
+	Limit     int
+}
+
+// AuditPage: Satu halaman audit log
+type AuditPage struct {
+	Items      []AuditEvent `json:"items"`
+	NextCursor string       `json:"next_cursor,omitempty"`
 }
 
 // --- INTERFACES ---
@@ -22,18 +279,48 @@ type Comment struct {
 // RedisRepository (Tetap dipertahankan untuk Caching)
 type RedisRepository interface {
 	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
+	SetMany(ctx context.Context, values map[string]interface{}, ttl time.Duration) error
 	Get(ctx context.Context, key string) (string, error)
+	MGet(ctx context.Context, keys ...string) ([]interface{}, error)
 	Del(ctx context.Context, key string) error
+	Incr(ctx context.Context, key string) (int64, error)
+	Expire(ctx context.Context, key string, ttl time.Duration) error
 	DeletePrefix(ctx context.Context, prefix string) error
+	AppendStream(ctx context.Context, stream string, values map[string]interface{}) error
+	Publish(ctx context.Context, channel, message string) error
+	// Subscribe blocking sampai ctx dibatalkan. onSubscribe dipanggil setiap kali (re)subscribe berhasil
+	Subscribe(ctx context.Context, channel string, onMessage func(payload string), onSubscribe func()) error
 }
 
 // CommentRepository (Kontrak untuk akses Database)
 type CommentRepository interface {
 	Create(ctx context.Context, comment *Comment) error
-	GetByStoryUUID(ctx context.Context, storyUUID string) ([]Comment, error)
+	GetByStoryUUID(ctx context.Context, storyUUID string, query CommentQuery) ([]Comment, error)
+	GetByUserID(ctx context.Context, userID string, query CommentQuery) ([]Comment, error)
+	GetStoryStats(ctx context.Context, storyUUID string, fresh bool) (*StoryStats, error)
+	GetCounts(ctx context.Context, storyUUIDs []string) (map[string]int64, error)
+	ReconcileCounts(ctx context.Context) ([]string, error)
+	Search(ctx context.Context, query SearchQuery) ([]SearchResult, error)
+	FuzzySearch(ctx context.Context, query SearchQuery) ([]SearchResult, error)
+	FindSimilar(ctx context.Context, comment *Comment, threshold float64, limit int) ([]SearchResult, error)
 	GetByID(ctx context.Context, id uint) (*Comment, error)
+	GetByPublicID(ctx context.Context, publicID string) (*Comment, error)
+	GetSiblings(ctx context.Context, comment *Comment, n int) (newer []Comment, older []Comment, err error)
+	CountNewer(ctx context.Context, comment *Comment) (int64, error)
+	GetAtOffset(ctx context.Context, storyUUID string, offset int64) (*Comment, error)
 	Update(ctx context.Context, comment *Comment) error
 	Delete(ctx context.Context, id uint) error
+	ArchiveByStory(ctx context.Context, storyUUID, reason string, batchSize int) (int64, error)
+	ResetStoryStats(ctx context.Context, storyUUID string) error
+	// Export & erasure data user, keyset berdasarkan id internal
+	ListForExport(ctx context.Context, userID string, afterID uint, limit int) ([]Comment, error)
+	ListArchivedForExport(ctx context.Context, userID string, afterID uint, limit int) ([]ArchivedComment, error)
+	EraseByUser(ctx context.Context, userID, policy string, batchSize int) (storyUUIDs []string, err error)
+	EraseArchivedByUser(ctx context.Context, userID, policy string) (int64, error)
+	// Backfill content_html, all = false hanya komentar yang belum pernah dirender
+	ListForRender(ctx context.Context, afterID uint, limit int, all bool) ([]Comment, error)
+	UpdateContentHTML(ctx context.Context, id uint, html string) error
 }
 
 // CommentUseCase (Kontrak untuk Business Logic)
@@ -41,14 +328,95 @@ type CommentUseCase interface {
 	// Create: User memposting komentar baru
 	Create(ctx context.Context, storyUUID, userID, content string) (*Comment, error)
 	
-	// GetByStoryUUID: Mengambil semua komentar untuk story tertentu
-	GetByStoryUUID(ctx context.Context, storyUUID string) ([]Comment, error)
+	// GetByStoryUUID: Mengambil komentar untuk story tertentu per halaman
+	GetByStoryUUID(ctx context.Context, storyUUID string, query CommentQuery) (*CommentPage, error)
+
+	// GetStoryStats: Jumlah komentar & waktu update terakhir (untuk HTTP caching)
+	GetStoryStats(ctx context.Context, storyUUID string) (*StoryStats, error)
 	
+	// GetCounts: Jumlah komentar published untuk banyak story sekaligus (feed khalif-stories)
+	GetCounts(ctx context.Context, storyUUIDs []string) (map[string]int64, error)
+
+	// ReconcileCounts: Memperbaiki drift tabel story_comment_stats, return jumlah story yang dikoreksi
+	ReconcileCounts(ctx context.Context) (int, error)
+
+	// Search: Pencarian full-text komentar dengan ranking & snippet
+	Search(ctx context.Context, viewer Viewer, query SearchQuery) (*SearchPage, error)
+
+	// FindDuplicates: Komentar lain (lintas story) yang isinya mirip, untuk deteksi spam oleh moderator
+	FindDuplicates(ctx context.Context, id string, threshold float64, limit int) ([]SearchResult, error)
+
+	// GetByUserID: Mengambil komentar milik user tertentu (halaman profil)
+	GetByUserID(ctx context.Context, userID string, viewer Viewer, query CommentQuery) (*CommentPage, error)
+
+	// GetByID: Mengambil satu komentar berdasarkan ULID (atau ID numerik lama)
+	GetByID(ctx context.Context, id string, viewer Viewer) (*Comment, error)
+
+	// GetContext: Komentar + N tetangga + cursor halaman tempat komentar berada
+	GetContext(ctx context.Context, id string, viewer Viewer, siblings, pageSize int) (*CommentContext, error)
+
 	// Update: Mengedit komentar (hanya pemilik komentar)
-	Update(ctx context.Context, id uint, userID, content string) (*Comment, error)
+	// id berupa ULID (atau ID numerik lama selama masa migrasi)
+	Update(ctx context.Context, id string, userID, content string) (*Comment, error)
 	
 	// Delete: Menghapus komentar (hanya pemilik komentar)
-	Delete(ctx context.Context, id uint, userID string) error
+	Delete(ctx context.Context, id string, userID string) error
+
+	// Moderate: Mengubah status komentar (hanya moderator, dicek di middleware)
+	Moderate(ctx context.Context, id string, status string) (*Comment, error)
+
+	// ListenInvalidations: Sinkronisasi L1 cache antar instance (background, blocking)
+	ListenInvalidations(ctx context.Context) error
+
+	// ArchiveStory: Memindahkan semua komentar story ke arsip (story dihapus upstream). Idempotent
+	ArchiveStory(ctx context.Context, storyUUID, reason string) (*StoryArchiveResult, error)
+
+	// ExportUser: Menulis arsip JSON semua komentar user (aktif & arsip) ke w secara streaming
+	ExportUser(ctx context.Context, userID string, w io.Writer) error
+
+	// EraseUser: Anonimkan / hapus semua komentar user sesuai ERASURE_POLICY. Idempotent
+	EraseUser(ctx context.Context, userID string) (*UserErasureResult, error)
+}
+
+// AuditRepository (Audit log append-only di Postgres)
+type AuditRepository interface {
+	Insert(ctx context.Context, event *AuditEvent) error
+	Query(ctx context.Context, query AuditQuery) ([]AuditEvent, error)
+	// DeleteBefore: Hanya untuk retensi, return jumlah baris yang dihapus (maksimal batchSize)
+	DeleteBefore(ctx context.Context, before time.Time, batchSize int) (int64, error)
+}
+
+// AuditUseCase (Query & retensi audit log, pencatatan dilakukan oleh CommentUseCase)
+type AuditUseCase interface {
+	// Query: Audit log dengan filter, untuk admin
+	Query(ctx context.Context, query AuditQuery) (*AuditPage, error)
+
+	// ApplyRetention: Menghapus event yang lebih tua dari AUDIT_RETENTION, return jumlah yang dihapus
+	ApplyRetention(ctx context.Context) (int64, error)
+}
+
+// APIKeyRepository (Penyimpanan API key di Postgres)
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *APIKey) error
+	GetByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	List(ctx context.Context) ([]APIKey, error)
+	Revoke(ctx context.Context, prefix string) error
+	TouchLastUsed(ctx context.Context, id uint, at time.Time) error
+}
+
+// APIKeyUseCase (Pengelolaan & autentikasi API key service)
+type APIKeyUseCase interface {
+	// Create: Membuat key baru, plaintext hanya dikembalikan sekali di sini
+	Create(ctx context.Context, service string, scopes []string) (key *APIKey, plaintext string, err error)
+
+	// List: Semua key termasuk yang sudah dicabut
+	List(ctx context.Context) ([]APIKey, error)
+
+	// Revoke: Mencabut key berdasarkan prefix, berlaku di semua instance setelah cache expired
+	Revoke(ctx context.Context, prefix string) error
+
+	// Authenticate: Memvalidasi key plaintext dari header X-API-Key
+	Authenticate(ctx context.Context, plaintext string) (*APIKey, error)
 }
 
 // --- ERRORS ---
@@ -1,17 +1,97 @@
 package domain
 
-import "errors"
+import "net/http"
+
+// Error: Error domain dengan kode stabil (kontrak dengan client, jangan diganti), status HTTP
+// dan pesan yang aman ditampilkan. Error selain ini (DB, Redis, dst) disamarkan jadi 500
+// oleh middleware.ErrorHandler
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+	parent  *Error
+	fields  []FieldError
+}
+
+// FieldError: Satu field input yang tidak valid. Code dipetakan ke pesan "validation.<code>"
+// di katalog i18n, Params mengisi placeholder pesan (misal {"param": "5000"})
+type FieldError struct {
+	Field  string
+	Code   string
+	Params map[string]string
+}
+
+func NewError(code string, status int, message string) *Error {
+	return &Error{Code: code, Status: status, Message: message}
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func (e *Error) ErrorCode() string { return e.Code }
+
+func (e *Error) HTTPStatus() int { return e.Status }
+
+// Unwrap: Error turunan (lihat Derive) tetap cocok dengan errors.Is terhadap induknya
+func (e *Error) Unwrap() error {
+	if e.parent == nil {
+		return nil
+	}
+	return e.parent
+}
+
+// Derive: Error yang lebih spesifik dengan status yang sama, errors.Is(turunan, e) tetap true
+func (e *Error) Derive(code, message string) *Error {
+	return &Error{Code: code, Status: e.Status, Message: message, parent: e}
+}
+
+// WithFields: Salinan e dengan detail field yang tidak valid, errors.Is(hasil, e) tetap true
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	return &Error{Code: e.Code, Status: e.Status, Message: e.Message, parent: e, fields: fields}
+}
+
+// EachField: Dipakai middleware.ErrorHandler untuk merender error per field tanpa bergantung ke domain
+func (e *Error) EachField(fn func(field, code string, params map[string]string)) {
+	for _, f := range e.fields {
+		fn(f.Field, f.Code, f.Params)
+	}
+}
 
 var (
 	// Generic Errors
-	ErrInternalServerError = errors.New("internal server error")
-	ErrBadParamInput       = errors.New("given param is not valid")
+	ErrInternalServerError = NewError("INTERNAL_ERROR", http.StatusInternalServerError, "internal server error")
+	ErrBadParamInput       = NewError("INVALID_PARAMETER", http.StatusBadRequest, "given param is not valid")
+
+	// Parameter Errors (turunan ErrBadParamInput)
+	ErrInvalidCommentID  = ErrBadParamInput.Derive("INVALID_COMMENT_ID", "invalid comment id")
+	ErrInvalidStoryID    = ErrBadParamInput.Derive("INVALID_STORY_ID", "story id is missing or invalid")
+	ErrInvalidUserID     = ErrBadParamInput.Derive("INVALID_USER_ID", "user id is missing or invalid")
+	ErrInvalidLimit      = ErrBadParamInput.Derive("INVALID_LIMIT", "invalid limit")
+	ErrInvalidPage       = ErrBadParamInput.Derive("INVALID_PAGE", "invalid page")
+	ErrInvalidCursor     = ErrBadParamInput.Derive("INVALID_CURSOR", "invalid cursor")
+	ErrInvalidSort       = ErrBadParamInput.Derive("INVALID_SORT", "invalid sort, use newest or oldest")
+	ErrInvalidSiblings   = ErrBadParamInput.Derive("INVALID_SIBLINGS", "invalid siblings")
+	ErrInvalidSearchMode = ErrBadParamInput.Derive("INVALID_SEARCH_MODE", "invalid search mode, use fulltext or fuzzy")
+	ErrInvalidThreshold  = ErrBadParamInput.Derive("INVALID_THRESHOLD", "threshold must be between 0 and 1")
+	ErrInvalidTimeRange  = ErrBadParamInput.Derive("INVALID_TIME_RANGE", "invalid time range, use RFC3339 and from before to")
 
 	// Resource Errors
-	ErrCommentNotFound = errors.New("comment not found")
-	ErrStoryNotFound   = errors.New("story not found") // Opsional, jika validasi story dilakukan di sini
+	ErrCommentNotFound = NewError("COMMENT_NOT_FOUND", http.StatusNotFound, "comment not found")
+	ErrStoryNotFound   = NewError("STORY_NOT_FOUND", http.StatusNotFound, "story not found") // Opsional, jika validasi story dilakukan di sini
 
 	// Permission & Validation Errors
-	ErrUnauthorizedAction = errors.New("you are not authorized to modify this comment") // User hanya boleh edit/delete punya sendiri
-	ErrEmptyContent       = errors.New("comment content cannot be empty")
-)
\ No newline at end of file
+	ErrUnauthorizedAction = NewError("FORBIDDEN_ACTION", http.StatusForbidden, "you are not authorized to modify this comment") // User hanya boleh edit/delete punya sendiri
+	ErrEmptyContent       = NewError("EMPTY_CONTENT", http.StatusBadRequest, "comment content cannot be empty")
+	ErrInvalidContent     = NewError("INVALID_CONTENT", http.StatusBadRequest, "comment content is not valid")
+	ErrInvalidStatus      = NewError("INVALID_STATUS", http.StatusBadRequest, "invalid comment status")
+	ErrEmptySearchQuery   = NewError("EMPTY_SEARCH_QUERY", http.StatusBadRequest, "search query cannot be empty")
+
+	// API Key Errors
+	ErrInvalidAPIKey    = NewError("INVALID_API_KEY", http.StatusUnauthorized, "invalid api key")
+	ErrAPIKeyNotFound   = NewError("API_KEY_NOT_FOUND", http.StatusNotFound, "api key not found")
+	ErrInvalidScope     = NewError("INVALID_SCOPE", http.StatusBadRequest, "unknown api key scope")
+	ErrEmptyServiceName = NewError("EMPTY_SERVICE_NAME", http.StatusBadRequest, "service name cannot be empty")
+
+	// Revocation Errors
+	ErrEmptyRevocation  = ErrBadParamInput.Derive("EMPTY_REVOCATION", "jti or user_id is required")
+	ErrRevocationFailed = NewError("REVOCATION_UNAVAILABLE", http.StatusServiceUnavailable, "failed to store revocation") // Service auth harus retry
+)
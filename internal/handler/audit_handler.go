@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"khalif-comment/internal/domain"
+	"khalif-comment/pkg/utils"
+
+)
+
+// AuditHandler: Endpoint admin untuk membaca audit log
+type AuditHandler struct {
+	useCase domain.AuditUseCase
+}
+
+func NewAuditHandler(u domain.AuditUseCase) *AuditHandler {
+	return &AuditHandler{useCase: u}
+}
+
+// --- HANDLERS ---
+
+// ListAuditEvents godoc
+// @Summary      List audit events
+// @Description  Append-only record of comment create/update/delete/moderation, story archiving and user erasure, newest first. Includes who acted (user, service or system), when, from which IP and request, plus before/after snapshots. Comment snapshots never contain the comment text or author user_id (only content_length and content_sha256), so erasing a user leaves nothing of theirs behind in this log. Admin only
+// @Tags         admin
+// @Produce      json
+// @Param        actor_id   query     string  false  "User ID or service name"
+// @Param        action     query     string  false  "e.g. comment.moderate"
+// @Param        target_id  query     string  false  "Comment ID, story ID or user ID"
+// @Param        story_id   query     string  false  "Story UUID"
+// @Param        from       query     string  false  "RFC3339, inclusive"
+// @Param        to         query     string  false  "RFC3339, exclusive"
+// @Param        limit      query     int     false  "Page size (default 50, max 200)"
+// @Param        cursor     query     string  false  "Cursor from previous page (meta.next_cursor)"
+// @Success      200  {array}   domain.AuditEvent
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      403  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /admin/audit-events [get]
+// @Security     BearerAuth
+func (h *AuditHandler) List(c *gin.Context) {
+	query := domain.AuditQuery{
+		ActorID:   c.Query("actor_id"),
+		Action:    c.Query("action"),
+		TargetID:  c.Query("target_id"),
+		StoryUUID: c.Query("story_id"),
+	}
+
+	var err error
+	if query.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "0")); err != nil {
+		c.Error(domain.ErrInvalidLimit)
+		return
+	}
+	if cursor := c.Query("cursor"); cursor != "" {
+		id, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil || id == 0 {
+			c.Error(domain.ErrInvalidCursor)
+			return
+		}
+		query.BeforeID = uint(id)
+	}
+	if query.From, err = parseTimeQuery(c, "from"); err != nil {
+		c.Error(domain.ErrInvalidTimeRange)
+		return
+	}
+	if query.To, err = parseTimeQuery(c, "to"); err != nil {
+		c.Error(domain.ErrInvalidTimeRange)
+		return
+	}
+
+	page, err := h.useCase.Query(c.Request.Context(), query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	utils.SuccessResponseWithMeta(c, http.StatusOK, page.Items, gin.H{
+		"next_cursor": page.NextCursor,
+	})
+}
+
+// parseTimeQuery: Query param kosong = nil
+func parseTimeQuery(c *gin.Context, name string) (*time.Time, error) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"khalif-comment/internal/domain"
+	"khalif-comment/pkg/auth"
+	"khalif-comment/pkg/utils"
+
+)
+
+// AuthHandler: Endpoint internal untuk service auth
+type AuthHandler struct {
+	revocations *auth.Revocations
+}
+
+func NewAuthHandler(r *auth.Revocations) *AuthHandler {
+	return &AuthHandler{revocations: r}
+}
+
+// --- DTOs ---
+
+// RevocationRequest: Isi jti untuk mencabut satu token (logout), user_id untuk semua token
+// user tersebut (ban / logout semua device). Keduanya boleh dikirim sekaligus
+type RevocationRequest struct {
+	JTI          string     `json:"jti" example:"01J9Z3K4T5V6W7X8Y9Z0ABCDEF"`
+	ExpiresAt    *time.Time `json:"expires_at"` // exp token, kosong = memakai REVOCATION_USER_TTL
+	UserID       string     `json:"user_id" example:"42"`
+	IssuedBefore *time.Time `json:"issued_before"` // Kosong = sekarang
+}
+
+// --- HANDLERS ---
+
+// Revoke godoc
+// @Summary      Revoke tokens
+// @Description  Called by the auth service on logout or ban. Revokes a single token by jti and/or every token of a user issued at or before issued_before
+// @Tags         internal
+// @Accept       json
+// @Produce      json
+// @Param        request body RevocationRequest true "Revocation"
+// @Success      200  {object}  utils.APIResponse
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      403  {object}  utils.APIResponse
+// @Failure      503  {object}  utils.APIResponse
+// @Router       /internal/revocations [post]
+// @Security     ApiKeyAuth
+func (h *AuthHandler) Revoke(c *gin.Context) {
+	var req RevocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+	if req.JTI == "" && req.UserID == "" {
+		c.Error(domain.ErrEmptyRevocation)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if req.JTI != "" {
+		var expiresAt time.Time
+		if req.ExpiresAt != nil {
+			expiresAt = *req.ExpiresAt
+		}
+		if err := h.revocations.RevokeToken(ctx, req.JTI, expiresAt); err != nil {
+			// Service auth harus retry, revocation tidak boleh hilang diam-diam
+			c.Error(fmt.Errorf("%w: %v", domain.ErrRevocationFailed, err))
+			return
+		}
+	}
+	if req.UserID != "" {
+		before := time.Now()
+		if req.IssuedBefore != nil {
+			before = *req.IssuedBefore
+		}
+		if err := h.revocations.RevokeUser(ctx, req.UserID, before); err != nil {
+			c.Error(fmt.Errorf("%w: %v", domain.ErrRevocationFailed, err))
+			return
+		}
+	}
+
+	utils.SuccessMessage(c, http.StatusOK, "TOKENS_REVOKED")
+}
@@ -1,13 +1,18 @@
 package handler
 
 import (
-	"errors"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 
 	"khalif-comment/internal/domain"
+	"khalif-comment/pkg/logger"
+	"khalif-comment/pkg/pagination"
 	"khalif-comment/pkg/utils"
 
 )
@@ -31,11 +36,22 @@ type UpdateCommentRequest struct {
 	Content string `json:"content" binding:"required"`
 }
 
+type CommentCountsRequest struct {
+	StoryIDs []string `json:"story_ids" binding:"required,min=1,max=100,dive,required"`
+}
+
+type ModerateCommentRequest struct {
+	Status string `json:"status" binding:"required" example:"hidden"`
+}
+
+// Public read boleh di-cache CDN sebentar, lalu revalidasi pakai ETag
+const listCacheControl = "public, max-age=15, s-maxage=60, stale-while-revalidate=30"
+
 // --- HANDLERS ---
 
 // CreateComment godoc
 // @Summary      Post a comment
-// @Description  Create a new comment for a story
+// @Description  Create a new comment for a story. Content supports a Markdown subset (**bold**, *italic*, `code`, [links](https://...), ||spoiler||, > quote) and is returned both as source (content) and sanitized HTML (content_html). Content is normalized first (NFC, trimmed, invisible characters removed, excessive blank lines collapsed) and its length is counted in grapheme clusters; violations return 400 with per-field errors in fields
 // @Tags         comments
 // @Accept       json
 // @Produce      json
@@ -48,7 +64,7 @@ type UpdateCommentRequest struct {
 func (h *CommentHandler) Create(c *gin.Context) {
 	var req CreateCommentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
@@ -56,7 +72,7 @@ func (h *CommentHandler) Create(c *gin.Context) {
 	
 	res, err := h.useCase.Create(c.Request.Context(), req.StoryID, userID, req.Content)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		c.Error(err)
 		return
 	}
 	utils.SuccessResponse(c, http.StatusCreated, res)
@@ -64,23 +80,321 @@ func (h *CommentHandler) Create(c *gin.Context) {
 
 // GetCommentsByStory godoc
 // @Summary      Get comments by story
-// @Description  Retrieve all comments for a specific story
+// @Description  Retrieve a page of comments for a specific story. Supports conditional GET via ETag / Last-Modified
 // @Tags         comments
 // @Produce      json
-// @Param        story_id query     string  true  "Story UUID"
+// @Param        story_id query     string  true   "Story UUID"
+// @Param        limit    query     int     false  "Page size (default 20, max 100)"
+// @Param        cursor   query     string  false  "Cursor from previous page (meta.next_cursor)"
+// @Param        sort     query     string  false  "newest (default) or oldest"
+// @Param        If-None-Match      header  string  false  "ETag from previous response"
+// @Param        If-Modified-Since  header  string  false  "Last-Modified from previous response"
 // @Success      200  {array}   domain.Comment
+// @Success      304  "Not Modified"
 // @Failure      400  {object}  utils.APIResponse
 // @Router       /comments [get]
 func (h *CommentHandler) GetByStory(c *gin.Context) {
 	storyID := c.Query("story_id")
 	if storyID == "" {
-		utils.ErrorResponse(c, http.StatusBadRequest, "story_id query parameter is required")
+		c.Error(domain.ErrInvalidStoryID)
+		return
+	}
+
+	query, err := parseCommentQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// Hitung ETag dari statistik story (murah, di-cache) sebelum mengambil list
+	stats, err := h.useCase.GetStoryStats(c.Request.Context(), storyID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Cache-Control", listCacheControl)
+	if utils.NotModified(c, listETag(storyID, query, stats), stats.LastUpdated) {
+		return
+	}
+
+	res, err := h.useCase.GetByStoryUUID(c.Request.Context(), storyID, query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// List basi tidak boleh memakai ETag dari statistik terbaru, client / CDN bisa
+	// menyimpan isi lama dengan validator baru dan tidak pernah refetch
+	if res.Stale {
+		c.Writer.Header().Del("ETag")
+		c.Writer.Header().Del("Last-Modified")
+		c.Header("Cache-Control", "no-store")
+	}
+
+	utils.SuccessResponseWithMeta(c, http.StatusOK, res.Items, gin.H{
+		"limit":       query.Limit,
+		"next_cursor": res.NextCursor,
+	})
+}
+
+// GetCommentCounts godoc
+// @Summary      Get comment counts for many stories
+// @Description  Batch lookup of published comment counts, keyed by story UUID (max 100 stories per request)
+// @Tags         comments
+// @Accept       json
+// @Produce      json
+// @Param        request body CommentCountsRequest true "Story UUIDs"
+// @Success      200  {object}  map[string]int64
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /comments/counts [post]
+func (h *CommentHandler) GetCounts(c *gin.Context) {
+	var req CommentCountsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	res, err := h.useCase.GetCounts(c.Request.Context(), req.StoryIDs)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, res)
+}
+
+// SearchComments godoc
+// @Summary      Search comments
+// @Description  Full-text search over comments (Indonesian stemming), ranked by relevance with highlighted snippets. Fuzzy mode uses trigram similarity and returns similarity scores
+// @Tags         comments
+// @Produce      json
+// @Param        q         query     string  true   "Search query (websearch syntax: quoted phrases, OR, -exclusion)"
+// @Param        mode      query     string  false  "fulltext (default) or fuzzy (trigram, typo tolerant)"
+// @Param        story_id  query     string  false  "Filter by story UUID"
+// @Param        user_id   query     string  false  "Filter by user ID"
+// @Param        page      query     int     false  "Page number (default 1)"
+// @Param        limit     query     int     false  "Page size (default 20, max 100)"
+// @Success      200  {array}   domain.SearchResult
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /comments/search [get]
+func (h *CommentHandler) Search(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		c.Error(domain.ErrInvalidPage)
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.Error(domain.ErrInvalidLimit)
+		return
+	}
+
+	query := domain.SearchQuery{
+		Query:     c.Query("q"),
+		Mode:      c.Query("mode"),
+		StoryUUID: c.Query("story_id"),
+		UserID:    c.Query("user_id"),
+		Page:      page,
+		Limit:     limit,
+	}
+
+	res, err := h.useCase.Search(c.Request.Context(), viewerFrom(c), query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	utils.SuccessResponseWithMeta(c, http.StatusOK, res.Items, gin.H{
+		"page":     max(page, 1),
+		"limit":    pagination.NormalizeLimit(limit),
+		"has_more": res.HasMore,
+	})
+}
+
+// GetCommentsByUser godoc
+// @Summary      Get comments by user
+// @Description  Retrieve a page of comments written by a user (profile page). Pending, hidden and shadow-banned comments are only included for the owner or a moderator
+// @Tags         comments
+// @Produce      json
+// @Param        user_id  path      string  true   "User ID"
+// @Param        limit    query     int     false  "Page size (default 20, max 100)"
+// @Param        cursor   query     string  false  "Cursor from previous page (meta.next_cursor)"
+// @Param        sort     query     string  false  "newest (default) or oldest"
+// @Success      200  {array}   domain.Comment
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /users/{user_id}/comments [get]
+func (h *CommentHandler) GetByUser(c *gin.Context) {
+	h.listByUser(c, c.Param("user_id"))
+}
+
+// GetMyComments godoc
+// @Summary      Get my comments
+// @Description  Retrieve a page of the current user's comments, including pending, hidden and shadow-banned ones
+// @Tags         comments
+// @Produce      json
+// @Param        limit    query     int     false  "Page size (default 20, max 100)"
+// @Param        cursor   query     string  false  "Cursor from previous page (meta.next_cursor)"
+// @Param        sort     query     string  false  "newest (default) or oldest"
+// @Success      200  {array}   domain.Comment
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /users/me/comments [get]
+// @Security     BearerAuth
+func (h *CommentHandler) GetMyComments(c *gin.Context) {
+	h.listByUser(c, c.GetString("user_id"))
+}
+
+// ExportMyData godoc
+// @Summary      Export my data
+// @Description  Download a JSON archive of every comment written by the current user (all statuses, including comments archived because their story was deleted). This service stores comments only, there are no revisions, reactions or reports to export.
+// @Description  The archive is streamed synchronously in this request instead of being prepared by a background job: it is built in batches with constant memory, so there is nothing to poll or download later. Errors before the first byte return 500; an error mid-stream truncates the body, which then is not valid JSON and must be retried
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  domain.UserExport  "Streamed archive: user_id, generated_at, comments[], archived_comments[]"
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /users/me/export [get]
+// @Security     BearerAuth
+func (h *CommentHandler) ExportMyData(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="khalif-comments-export.json"`)
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	err := h.useCase.ExportUser(c.Request.Context(), userID, c.Writer)
+	if err == nil {
 		return
 	}
 
-	res, err := h.useCase.GetByStoryUUID(c.Request.Context(), storyID)
+	// Status 200 baru benar-benar dikirim saat byte pertama ditulis. Jika belum ada yang terkirim,
+	// kirim error biasa lewat ErrorHandler (bukan 200 dengan body kosong yang terlihat seperti arsip)
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Disposition")
+		c.Error(err)
+		return
+	}
+
+	// Sudah di tengah stream, error hanya bisa dicatat (arsip terpotong, bukan JSON valid)
+	logger.Error("User data export failed", zap.String("user_id", userID), zap.Error(err))
+	c.Abort()
+}
+
+// EraseMyData godoc
+// @Summary      Erase my comments
+// @Description  Anonymize or permanently delete every comment of the current user, depending on the server erasure policy. Cannot be undone
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  domain.UserErasureResult
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /users/me/comments [delete]
+// @Security     BearerAuth
+func (h *CommentHandler) EraseMyData(c *gin.Context) {
+	h.eraseUser(c, c.GetString("user_id"))
+}
+
+// EraseUserData godoc
+// @Summary      Erase a user's comments
+// @Description  Called by the auth service when an account is deleted. Anonymizes or deletes every comment of the user according to the erasure policy. Idempotent
+// @Tags         internal
+// @Produce      json
+// @Param        user_id  path      string  true  "User ID"
+// @Success      200  {object}  domain.UserErasureResult
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      403  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /internal/users/{user_id}/erasure [post]
+// @Security     ApiKeyAuth
+func (h *CommentHandler) EraseUserData(c *gin.Context) {
+	h.eraseUser(c, c.Param("user_id"))
+}
+
+func (h *CommentHandler) eraseUser(c *gin.Context, userID string) {
+	res, err := h.useCase.EraseUser(c.Request.Context(), userID)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		c.Error(err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, res)
+}
+
+func (h *CommentHandler) listByUser(c *gin.Context, userID string) {
+	if userID == "" {
+		c.Error(domain.ErrInvalidUserID)
+		return
+	}
+
+	query, err := parseCommentQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	res, err := h.useCase.GetByUserID(c.Request.Context(), userID, viewerFrom(c), query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	utils.SuccessResponseWithMeta(c, http.StatusOK, res.Items, gin.H{
+		"limit":       query.Limit,
+		"next_cursor": res.NextCursor,
+	})
+}
+
+// GetComment godoc
+// @Summary      Get a comment
+// @Description  Retrieve a single comment by ID
+// @Tags         comments
+// @Produce      json
+// @Param        id   path      string  true  "Comment ID (ULID)"
+// @Success      200  {object}  domain.Comment
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /comments/{id} [get]
+func (h *CommentHandler) GetByID(c *gin.Context) {
+	res, err := h.useCase.GetByID(c.Request.Context(), c.Param("id"), viewerFrom(c))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, res)
+}
+
+// GetCommentContext godoc
+// @Summary      Get comment permalink context
+// @Description  Retrieve a comment with its surrounding comments and the page cursor of the story list where it appears.
+// @Description  Comments are flat (no replies, no parent_id), so there are no ancestors to return: the context is the neighbouring comments in the story list only
+// @Tags         comments
+// @Produce      json
+// @Param        id        path      string  true   "Comment ID (ULID)"
+// @Param        siblings  query     int     false  "Number of surrounding comments on each side (default 3, max 20)"
+// @Param        limit     query     int     false  "Page size used by the client for the story list (default 20, max 100)"
+// @Success      200  {object}  domain.CommentContext
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /comments/{id}/context [get]
+func (h *CommentHandler) GetContext(c *gin.Context) {
+	siblings, err := strconv.Atoi(c.DefaultQuery("siblings", "0"))
+	if err != nil {
+		c.Error(domain.ErrInvalidSiblings)
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.Error(domain.ErrInvalidLimit)
+		return
+	}
+
+	res, err := h.useCase.GetContext(c.Request.Context(), c.Param("id"), viewerFrom(c), siblings, limit)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 	utils.SuccessResponse(c, http.StatusOK, res)
@@ -88,11 +402,11 @@ func (h *CommentHandler) GetByStory(c *gin.Context) {
 
 // UpdateComment godoc
 // @Summary      Update a comment
-// @Description  Update comment content (Owner only)
+// @Description  Update comment content (Owner only). content_html is re-rendered from the new Markdown source. Content is normalized and length-checked the same way as on create
 // @Tags         comments
 // @Accept       json
 // @Produce      json
-// @Param        id       path      int                   true  "Comment ID"
+// @Param        id       path      string                true  "Comment ID (ULID)"
 // @Param        request  body      UpdateCommentRequest  true  "Update Data"
 // @Success      200  {object}  domain.Comment
 // @Failure      400  {object}  utils.APIResponse
@@ -102,32 +416,20 @@ func (h *CommentHandler) GetByStory(c *gin.Context) {
 // @Router       /comments/{id} [put]
 // @Security     BearerAuth
 func (h *CommentHandler) Update(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := strconv.Atoi(idParam)
-	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "invalid comment id")
-		return
-	}
+	// ID berupa ULID, ID numerik lama di-resolve oleh usecase selama masa migrasi
+	id := c.Param("id")
 
 	var req UpdateCommentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		c.Error(err).SetType(gin.ErrorTypeBind)
 		return
 	}
 
 	userID := c.GetString("user_id")
 
-	res, err := h.useCase.Update(c.Request.Context(), uint(id), userID, req.Content)
+	res, err := h.useCase.Update(c.Request.Context(), id, userID, req.Content)
 	if err != nil {
-		if errors.Is(err, domain.ErrCommentNotFound) {
-			utils.ErrorResponse(c, http.StatusNotFound, err.Error())
-			return
-		}
-		if errors.Is(err, domain.ErrUnauthorizedAction) {
-			utils.ErrorResponse(c, http.StatusForbidden, err.Error()) // 403 Forbidden
-			return
-		}
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+		c.Error(err)
 		return
 	}
 	utils.SuccessResponse(c, http.StatusOK, res)
@@ -138,34 +440,159 @@ func (h *CommentHandler) Update(c *gin.Context) {
 // @Description  Delete a comment by ID (Owner only)
 // @Tags         comments
 // @Produce      json
-// @Param        id   path      int     true  "Comment ID"
+// @Param        id   path      string  true  "Comment ID (ULID)"
 // @Success      200  {object}  utils.APIResponse
+// @Failure      400  {object}  utils.APIResponse
 // @Failure      403  {object}  utils.APIResponse "Unauthorized Action"
 // @Failure      404  {object}  utils.APIResponse
 // @Failure      500  {object}  utils.APIResponse
 // @Router       /comments/{id} [delete]
 // @Security     BearerAuth
 func (h *CommentHandler) Delete(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := strconv.Atoi(idParam)
+	// ID berupa ULID, ID numerik lama di-resolve oleh usecase selama masa migrasi
+	id := c.Param("id")
+
+	userID := c.GetString("user_id")
+
+	if err := h.useCase.Delete(c.Request.Context(), id, userID); err != nil {
+		c.Error(err)
+		return
+	}
+	utils.SuccessMessage(c, http.StatusOK, "COMMENT_DELETED")
+}
+// ModerateComment godoc
+// @Summary      Moderate a comment
+// @Description  Change the moderation status of a comment: published, pending, hidden or shadow_banned (Moderator only)
+// @Tags         moderation
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "Comment ID (ULID)"
+// @Param        request  body      ModerateCommentRequest  true  "New Status"
+// @Success      200  {object}  domain.Comment
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      403  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /moderation/comments/{id} [patch]
+// @Security     BearerAuth
+func (h *CommentHandler) Moderate(c *gin.Context) {
+	var req ModerateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(err).SetType(gin.ErrorTypeBind)
+		return
+	}
+
+	res, err := h.useCase.Moderate(c.Request.Context(), c.Param("id"), req.Status)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "invalid comment id")
+		c.Error(err)
 		return
 	}
+	utils.SuccessResponse(c, http.StatusOK, res)
+}
 
-	userID := c.GetString("user_id")
+// FindDuplicateComments godoc
+// @Summary      Find near-duplicate comments
+// @Description  Find comments across all stories whose content is similar to the given comment (trigram similarity), to spot copy-paste spam (Moderator only)
+// @Tags         moderation
+// @Produce      json
+// @Param        id         path      string  true   "Comment ID (ULID)"
+// @Param        threshold  query     number  false  "Minimum similarity 0..1 (default 0.6)"
+// @Param        limit      query     int     false  "Max results (default 20, max 100)"
+// @Success      200  {array}   domain.SearchResult
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      403  {object}  utils.APIResponse
+// @Failure      404  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /moderation/comments/{id}/duplicates [get]
+// @Security     BearerAuth
+func (h *CommentHandler) FindDuplicates(c *gin.Context) {
+	threshold, err := strconv.ParseFloat(c.DefaultQuery("threshold", "0"), 64)
+	if err != nil {
+		c.Error(domain.ErrInvalidThreshold)
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		c.Error(domain.ErrInvalidLimit)
+		return
+	}
 
-	if err := h.useCase.Delete(c.Request.Context(), uint(id), userID); err != nil {
-		if errors.Is(err, domain.ErrCommentNotFound) {
-			utils.ErrorResponse(c, http.StatusNotFound, err.Error())
-			return
-		}
-		if errors.Is(err, domain.ErrUnauthorizedAction) {
-			utils.ErrorResponse(c, http.StatusForbidden, err.Error()) // 403 Forbidden
-			return
-		}
-		utils.ErrorResponse(c, http.StatusInternalServerError, err.Error())
+	res, err := h.useCase.FindDuplicates(c.Request.Context(), c.Param("id"), threshold, limit)
+	if err != nil {
+		c.Error(err)
 		return
 	}
-	utils.SuccessMessage(c, http.StatusOK, "comment deleted")
-}
\ No newline at end of file
+	utils.SuccessResponse(c, http.StatusOK, res)
+}
+
+// ArchiveStoryComments godoc
+// @Summary      Archive all comments of a deleted story
+// @Description  Called by khalif-stories when a story is removed. Moves every comment of the story to the archive in batches, clears its caches and emits one story.comments_archived event. Idempotent
+// @Tags         internal
+// @Produce      json
+// @Param        story_uuid  path      string  true  "Story UUID"
+// @Success      200  {object}  domain.StoryArchiveResult
+// @Failure      400  {object}  utils.APIResponse
+// @Failure      401  {object}  utils.APIResponse
+// @Failure      403  {object}  utils.APIResponse
+// @Failure      500  {object}  utils.APIResponse
+// @Router       /internal/stories/{story_uuid}/comments [delete]
+// @Security     ApiKeyAuth
+func (h *CommentHandler) ArchiveStory(c *gin.Context) {
+	res, err := h.useCase.ArchiveStory(c.Request.Context(), c.Param("story_uuid"), domain.ArchiveReasonStoryDeleted)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, res)
+}
+
+// --- HELPERS ---
+
+// viewerFrom: Identitas viewer dari middleware Auth / OptionalAuth (kosong = anonim)
+func viewerFrom(c *gin.Context) domain.Viewer {
+	return domain.Viewer{
+		UserID: c.GetString("user_id"),
+		Role:   c.GetString("role"),
+	}
+}
+
+// parseCommentQuery: Membaca limit, cursor & sort dari query string
+func parseCommentQuery(c *gin.Context) (domain.CommentQuery, error) {
+	var query domain.CommentQuery
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			return query, domain.ErrInvalidLimit
+		}
+		query.Limit = limit
+	}
+	query.Limit = pagination.NormalizeLimit(query.Limit)
+
+	switch sort := c.DefaultQuery("sort", domain.SortNewest); sort {
+	case domain.SortNewest, domain.SortOldest:
+		query.Sort = sort
+	default:
+		return query, domain.ErrInvalidSort
+	}
+
+	after, err := pagination.Decode(c.Query("cursor"))
+	if err != nil {
+		return query, domain.ErrInvalidCursor
+	}
+	query.After = after
+
+	return query, nil
+}
+
+// listETag: Strong ETag per story & per halaman, berubah setiap ada create/update/delete
+func listETag(storyID string, query domain.CommentQuery, stats *domain.StoryStats) string {
+	cursor := ""
+	if query.After != nil {
+		cursor = query.After.Encode()
+	}
+	raw := fmt.Sprintf("%s|%s|%d|%s|%d|%d", storyID, query.Sort, query.Limit, cursor, stats.Count, stats.LastUpdated.UnixNano())
+	sum := sha256.Sum256([]byte(raw))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
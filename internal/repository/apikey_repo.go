@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"khalif-comment/internal/domain"
+
+)
+
+type APIKeyRepo struct {
+	db *gorm.DB // Selalu primary: key yang baru dibuat / dicabut harus langsung terlihat
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepo {
+	return &APIKeyRepo{db: db}
+}
+
+// apiKeyRow: scopes (TEXT[]) dibaca / ditulis sebagai string dipisah koma,
+// karena database/sql tidak bisa scan array Postgres langsung ke []string
+type apiKeyRow struct {
+	ID         uint
+	Prefix     string
+	KeyHash    string
+	Service    string
+	Scopes     string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+const apiKeyColumns = "id, prefix, key_hash, service, array_to_string(scopes, ',') AS scopes, created_at, last_used_at, revoked_at"
+
+func (row apiKeyRow) toDomain() domain.APIKey {
+	key := domain.APIKey{
+		ID:         row.ID,
+		Prefix:     row.Prefix,
+		KeyHash:    row.KeyHash,
+		Service:    row.Service,
+		Scopes:     []string{},
+		CreatedAt:  row.CreatedAt,
+		LastUsedAt: row.LastUsedAt,
+		RevokedAt:  row.RevokedAt,
+	}
+	if row.Scopes != "" {
+		key.Scopes = strings.Split(row.Scopes, ",")
+	}
+	return key
+}
+
+// Create: Menyimpan key baru, ID & CreatedAt diisi dari database
+func (r *APIKeyRepo) Create(ctx context.Context, key *domain.APIKey) error {
+	var row apiKeyRow
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO api_keys (prefix, key_hash, service, scopes)
+		VALUES (?, ?, ?, string_to_array(?, ','))
+		RETURNING `+apiKeyColumns,
+		key.Prefix, key.KeyHash, key.Service, strings.Join(key.Scopes, ",")).
+		Scan(&row).Error
+	if err != nil {
+		return err
+	}
+	*key = row.toDomain()
+	return nil
+}
+
+// GetByPrefix: Termasuk key yang sudah dicabut, pengecekan revoked_at dilakukan di usecase
+func (r *APIKeyRepo) GetByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	var rows []apiKeyRow
+	err := r.db.WithContext(ctx).Raw(`SELECT `+apiKeyColumns+` FROM api_keys WHERE prefix = ?`, prefix).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+	key := rows[0].toDomain()
+	return &key, nil
+}
+
+// List: Semua key, terbaru di atas
+func (r *APIKeyRepo) List(ctx context.Context) ([]domain.APIKey, error) {
+	var rows []apiKeyRow
+	err := r.db.WithContext(ctx).Raw(`SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at DESC, id DESC`).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]domain.APIKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.toDomain())
+	}
+	return keys, nil
+}
+
+// Revoke: Idempotent, revoked_at pertama dipertahankan
+func (r *APIKeyRepo) Revoke(ctx context.Context, prefix string) error {
+	res := r.db.WithContext(ctx).Exec(
+		`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE prefix = ?`, prefix)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return domain.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed: Dipanggil dengan throttle dari usecase, bukan setiap request
+func (r *APIKeyRepo) TouchLastUsed(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Exec(`UPDATE api_keys SET last_used_at = ? WHERE id = ?`, at, id).Error
+}
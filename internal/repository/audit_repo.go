@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"khalif-comment/internal/domain"
+
+)
+
+type AuditRepo struct {
+	db *gorm.DB // Selalu primary: audit harus langsung terbaca setelah aksi
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepo {
+	return &AuditRepo{db: db}
+}
+
+// auditRow: before/after JSONB dibaca sebagai text agar bisa di-scan ke json.RawMessage
+type auditRow struct {
+	domain.AuditEvent
+	BeforeJSON *string `gorm:"column:before_json"`
+	AfterJSON  *string `gorm:"column:after_json"`
+}
+
+// Insert: occurred_at diisi database jika kosong
+func (r *AuditRepo) Insert(ctx context.Context, e *domain.AuditEvent) error {
+	return r.db.WithContext(ctx).Raw(`
+		INSERT INTO audit_events (occurred_at, actor_type, actor_id, actor_role, action, target_type, target_id,
+		                          story_uuid, before, after, ip, request_id)
+		VALUES (COALESCE(?, NOW()), ?, ?, ?, ?, ?, ?, ?, ?::jsonb, ?::jsonb, ?, ?)
+		RETURNING id, occurred_at`,
+		nullTime(e.OccurredAt), e.ActorType, e.ActorID, e.ActorRole, e.Action, e.TargetType, e.TargetID,
+		e.StoryUUID, nullJSON(e.Before), nullJSON(e.After), e.IP, e.RequestID).
+		Row().Scan(&e.ID, &e.OccurredAt)
+}
+
+// Query: Filter opsional, terbaru di atas, mengambil limit+1 baris untuk deteksi halaman berikutnya
+func (r *AuditRepo) Query(ctx context.Context, q domain.AuditQuery) ([]domain.AuditEvent, error) {
+	tx := r.db.WithContext(ctx).Table("audit_events").
+		Select(`id, occurred_at, actor_type, actor_id, actor_role, action, target_type, target_id, story_uuid, ip, request_id,
+			before::text AS before_json, after::text AS after_json`)
+	if q.ActorID != "" {
+		tx = tx.Where("actor_id = ?", q.ActorID)
+	}
+	if q.Action != "" {
+		tx = tx.Where("action = ?", q.Action)
+	}
+	if q.TargetID != "" {
+		tx = tx.Where("target_id = ?", q.TargetID)
+	}
+	if q.StoryUUID != "" {
+		tx = tx.Where("story_uuid = ?", q.StoryUUID)
+	}
+	if q.From != nil {
+		tx = tx.Where("occurred_at >= ?", *q.From)
+	}
+	if q.To != nil {
+		tx = tx.Where("occurred_at < ?", *q.To)
+	}
+	if q.BeforeID > 0 {
+		tx = tx.Where("id < ?", q.BeforeID)
+	}
+
+	var rows []auditRow
+	if err := tx.Order("id desc").Limit(q.Limit + 1).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	events := make([]domain.AuditEvent, 0, len(rows))
+	for _, row := range rows {
+		e := row.AuditEvent
+		if row.BeforeJSON != nil {
+			e.Before = []byte(*row.BeforeJSON)
+		}
+		if row.AfterJSON != nil {
+			e.After = []byte(*row.AfterJSON)
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// DeleteBefore: Trigger append-only hanya mengizinkan DELETE dengan flag khalif.audit_retention
+// yang di-set lokal di transaksi ini
+func (r *AuditRepo) DeleteBefore(ctx context.Context, before time.Time, batchSize int) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SET LOCAL khalif.audit_retention = 'on'").Error; err != nil {
+			return err
+		}
+		res := tx.Exec(`
+			DELETE FROM audit_events WHERE id IN (
+				SELECT id FROM audit_events WHERE occurred_at < ? ORDER BY id LIMIT ?
+			)`, before, batchSize)
+		deleted = res.RowsAffected
+		return res.Error
+	})
+	return deleted, err
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func nullJSON(data []byte) *string {
+	if len(data) == 0 {
+		return nil
+	}
+	s := string(data)
+	return &s
+}
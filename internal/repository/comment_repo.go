@@ -3,19 +3,32 @@ package repository
 import (
 	"context"
 	"errors"
+	"strconv"
+	"time"
 
 	"gorm.io/gorm"
 
 	"khalif-comment/internal/domain"
+	"khalif-comment/pkg/database"
 
 )
 
 type CommentRepo struct {
-	db *gorm.DB
+	db      *gorm.DB          // Primary: write & read-your-writes (GetByID sebelum update/delete)
+	replica *database.Replica // Read replica dengan fallback otomatis ke primary
 }
 
-func NewCommentRepository(db *gorm.DB) *CommentRepo {
-	return &CommentRepo{db: db}
+func NewCommentRepository(db *gorm.DB, replica *database.Replica) *CommentRepo {
+	return &CommentRepo{db: db, replica: replica}
+}
+
+// reader: fresh = primary. Hasil yang di-cache lama (list & statistik story) harus dibaca dari primary,
+// replica yang tertinggal sampai REPLICA_MAX_LAG akan membuat komentar baru hilang selama TTL cache
+func (r *CommentRepo) reader(fresh bool) *gorm.DB {
+	if fresh {
+		return r.db
+	}
+	return r.replica.Reader()
 }
 
 // Create: Menyimpan komentar baru ke database
@@ -23,17 +36,206 @@ func (r *CommentRepo) Create(ctx context.Context, c *domain.Comment) error {
 	return r.db.WithContext(ctx).Create(c).Error
 }
 
-// GetByStoryUUID: Mengambil semua komentar berdasarkan Story UUID
-func (r *CommentRepo) GetByStoryUUID(ctx context.Context, storyUUID string) ([]domain.Comment, error) {
+// GetByStoryUUID: Mengambil komentar published berdasarkan Story UUID per halaman (keyset pagination)
+// Mengambil limit+1 baris agar usecase bisa tahu apakah masih ada halaman berikutnya
+func (r *CommentRepo) GetByStoryUUID(ctx context.Context, storyUUID string, query domain.CommentQuery) ([]domain.Comment, error) {
 	var comments []domain.Comment
-	// Kita urutkan descending (terbaru di atas) agar user langsung melihat komentar baru
-	err := r.db.WithContext(ctx).
+	err := r.reader(query.Fresh).WithContext(ctx).
 		Where("story_uuid = ?", storyUUID).
-		Order("created_at desc").
+		Scopes(publishedOnly, paginate(query)).
 		Find(&comments).Error
 	return comments, err
 }
 
+// GetByUserID: Mengambil komentar milik user per halaman (memakai index user_id)
+func (r *CommentRepo) GetByUserID(ctx context.Context, userID string, query domain.CommentQuery) ([]domain.Comment, error) {
+	var comments []domain.Comment
+	// Pemilik melihat profilnya sendiri (read-your-writes) -> primary, pengunjung lain -> replica
+	db := r.replica.Reader()
+	if query.IncludeNonPublic {
+		db = r.db
+	}
+
+	tx := db.WithContext(ctx).Where("user_id = ?", userID)
+	if !query.IncludeNonPublic {
+		tx = tx.Scopes(publishedOnly)
+	}
+	err := tx.Scopes(paginate(query)).Find(&comments).Error
+	return comments, err
+}
+
+// GetStoryStats: Jumlah komentar published & waktu perubahan terakhir story.
+// LastUpdated diambil dari story_comment_stats.updated_at yang dinaikkan trigger di setiap perubahan
+// dan tidak pernah mundur, bukan MAX(updated_at) yang mundur saat komentar terbaru dihapus / disembunyikan
+func (r *CommentRepo) GetStoryStats(ctx context.Context, storyUUID string, fresh bool) (*domain.StoryStats, error) {
+	var row struct {
+		Count       int64
+		LastUpdated *time.Time
+	}
+	err := r.reader(fresh).WithContext(ctx).Raw(`
+		SELECT
+			(SELECT COUNT(*) FROM comments WHERE story_uuid = ? AND status = ?) AS count,
+			(SELECT updated_at FROM story_comment_stats WHERE story_uuid = ?) AS last_updated`,
+		storyUUID, domain.CommentStatusPublished, storyUUID).
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &domain.StoryStats{Count: row.Count}
+	if row.LastUpdated != nil {
+		stats.LastUpdated = *row.LastUpdated
+	}
+	return stats, nil
+}
+
+// GetCounts: Membaca jumlah komentar dari tabel denormalisasi story_comment_stats
+// Story yang belum punya baris dianggap 0
+func (r *CommentRepo) GetCounts(ctx context.Context, storyUUIDs []string) (map[string]int64, error) {
+	var rows []struct {
+		StoryUUID    string
+		CommentCount int64
+	}
+	err := r.replica.Reader().WithContext(ctx).
+		Table("story_comment_stats").
+		Select("story_uuid, comment_count").
+		Where("story_uuid IN ?", storyUUIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(storyUUIDs))
+	for _, id := range storyUUIDs {
+		counts[id] = 0
+	}
+	for _, row := range rows {
+		counts[row.StoryUUID] = row.CommentCount
+	}
+	return counts, nil
+}
+
+// ReconcileCounts: Menghitung ulang story_comment_stats dari tabel comments
+// dan mengembalikan story yang jumlahnya sempat melenceng
+func (r *CommentRepo) ReconcileCounts(ctx context.Context) ([]string, error) {
+	var fixed []string
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var upserted []string
+		err := tx.Raw(`
+			WITH actual AS (
+				SELECT story_uuid, COUNT(*) AS cnt FROM comments WHERE status = ? GROUP BY story_uuid
+			)
+			INSERT INTO story_comment_stats (story_uuid, comment_count, updated_at)
+			SELECT story_uuid, cnt, NOW() FROM actual
+			ON CONFLICT (story_uuid) DO UPDATE
+				SET comment_count = EXCLUDED.comment_count, updated_at = GREATEST(story_comment_stats.updated_at, NOW())
+				WHERE story_comment_stats.comment_count <> EXCLUDED.comment_count
+			RETURNING story_uuid`, domain.CommentStatusPublished).
+			Scan(&upserted).Error
+		if err != nil {
+			return err
+		}
+
+		// Story yang sudah tidak punya komentar published tapi masih tercatat > 0
+		var zeroed []string
+		err = tx.Raw(`
+			UPDATE story_comment_stats s SET comment_count = 0, updated_at = GREATEST(s.updated_at, NOW())
+			WHERE s.comment_count <> 0
+			  AND NOT EXISTS (SELECT 1 FROM comments c WHERE c.story_uuid = s.story_uuid AND c.status = ?)
+			RETURNING s.story_uuid`, domain.CommentStatusPublished).
+			Scan(&zeroed).Error
+		if err != nil {
+			return err
+		}
+
+		fixed = append(upserted, zeroed...)
+		return nil
+	})
+
+	return fixed, err
+}
+
+// Search: Full-text search memakai kolom generated search_vector (GIN index)
+// Konten di-escape sebelum ts_headline agar snippet aman dirender sebagai HTML
+func (r *CommentRepo) Search(ctx context.Context, query domain.SearchQuery) ([]domain.SearchResult, error) {
+	var results []domain.SearchResult
+
+	tx := r.replica.Reader().WithContext(ctx).
+		Table("comments").
+		Select(`comments.*,
+			ts_rank_cd(comments.search_vector, q) AS rank,
+			ts_headline('khalif_comment',
+				replace(replace(replace(comments.content, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+				q, 'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2') AS snippet`).
+		Joins("CROSS JOIN websearch_to_tsquery('khalif_comment', ?) AS q", query.Query).
+		Where("comments.search_vector @@ q")
+
+	if !query.IncludeNonPublic {
+		tx = tx.Where("comments.status = ?", domain.CommentStatusPublished)
+	}
+	if query.StoryUUID != "" {
+		tx = tx.Where("comments.story_uuid = ?", query.StoryUUID)
+	}
+	if query.UserID != "" {
+		tx = tx.Where("comments.user_id = ?", query.UserID)
+	}
+
+	// Ambil limit+1 untuk menentukan has_more
+	err := tx.Order("rank DESC, comments.created_at DESC").
+		Limit(query.Limit + 1).
+		Offset((query.Page - 1) * query.Limit).
+		Scan(&results).Error
+	return results, err
+}
+
+// FuzzySearch: Pencarian trigram (word_similarity) untuk typo & slang, memakai GIN index pg_trgm
+func (r *CommentRepo) FuzzySearch(ctx context.Context, query domain.SearchQuery) ([]domain.SearchResult, error) {
+	var results []domain.SearchResult
+
+	tx := r.replica.Reader().WithContext(ctx).
+		Table("comments").
+		Select("comments.*, word_similarity(?, comments.content) AS similarity, word_similarity(?, comments.content) AS rank", query.Query, query.Query).
+		Where("? <% comments.content", query.Query)
+
+	if !query.IncludeNonPublic {
+		tx = tx.Where("comments.status = ?", domain.CommentStatusPublished)
+	}
+	if query.StoryUUID != "" {
+		tx = tx.Where("comments.story_uuid = ?", query.StoryUUID)
+	}
+	if query.UserID != "" {
+		tx = tx.Where("comments.user_id = ?", query.UserID)
+	}
+
+	err := tx.Order("similarity DESC, comments.created_at DESC").
+		Limit(query.Limit + 1).
+		Offset((query.Page - 1) * query.Limit).
+		Scan(&results).Error
+	return results, err
+}
+
+// FindSimilar: Mencari komentar lain (semua story & status) yang mirip dengan komentar tertentu
+// Threshold di-set per transaksi agar operator % tetap memakai index trigram
+func (r *CommentRepo) FindSimilar(ctx context.Context, c *domain.Comment, threshold float64, limit int) ([]domain.SearchResult, error) {
+	var results []domain.SearchResult
+
+	err := r.replica.Reader().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_config('pg_trgm.similarity_threshold', ?, true)", strconv.FormatFloat(threshold, 'f', 2, 64)).Error; err != nil {
+			return err
+		}
+
+		return tx.Table("comments").
+			Select("comments.*, similarity(comments.content, ?) AS similarity, similarity(comments.content, ?) AS rank", c.Content, c.Content).
+			Where("comments.content % ? AND comments.id <> ?", c.Content, c.ID).
+			Order("similarity DESC, comments.created_at DESC").
+			Limit(limit).
+			Scan(&results).Error
+	})
+
+	return results, err
+}
+
 // GetByID: Mencari satu komentar (penting untuk validasi update/delete)
 func (r *CommentRepo) GetByID(ctx context.Context, id uint) (*domain.Comment, error) {
 	var comment domain.Comment
@@ -47,6 +249,80 @@ func (r *CommentRepo) GetByID(ctx context.Context, id uint) (*domain.Comment, er
 	return &comment, nil
 }
 
+// GetByPublicID: Mencari satu komentar berdasarkan ULID publik
+func (r *CommentRepo) GetByPublicID(ctx context.Context, publicID string) (*domain.Comment, error) {
+	var comment domain.Comment
+	err := r.db.WithContext(ctx).Where("public_id = ?", publicID).First(&comment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// GetSiblings: Mengambil n komentar sebelum (lebih baru) dan sesudah (lebih lama) komentar tertentu
+// Hasil newer & older sama-sama diurutkan seperti list story (terbaru di atas)
+func (r *CommentRepo) GetSiblings(ctx context.Context, c *domain.Comment, n int) ([]domain.Comment, []domain.Comment, error) {
+	var newer, older []domain.Comment
+
+	err := r.replica.Reader().WithContext(ctx).
+		Where("story_uuid = ? AND (created_at, public_id) > (?, ?)", c.StoryUUID, c.CreatedAt, c.PublicID).
+		Scopes(publishedOnly).
+		Order("created_at asc, public_id asc").
+		Limit(n).
+		Find(&newer).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	// Dibalik agar urutannya descending seperti list
+	for i, j := 0, len(newer)-1; i < j; i, j = i+1, j-1 {
+		newer[i], newer[j] = newer[j], newer[i]
+	}
+
+	err = r.replica.Reader().WithContext(ctx).
+		Where("story_uuid = ? AND (created_at, public_id) < (?, ?)", c.StoryUUID, c.CreatedAt, c.PublicID).
+		Scopes(publishedOnly).
+		Order("created_at desc, public_id desc").
+		Limit(n).
+		Find(&older).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newer, older, nil
+}
+
+// CountNewer: Menghitung jumlah komentar yang tampil di atas komentar tertentu (posisinya di list)
+func (r *CommentRepo) CountNewer(ctx context.Context, c *domain.Comment) (int64, error) {
+	var count int64
+	err := r.replica.Reader().WithContext(ctx).
+		Model(&domain.Comment{}).
+		Where("story_uuid = ? AND (created_at, public_id) > (?, ?)", c.StoryUUID, c.CreatedAt, c.PublicID).
+		Scopes(publishedOnly).
+		Count(&count).Error
+	return count, err
+}
+
+// GetAtOffset: Mengambil komentar pada index tertentu di list story (dipakai untuk membentuk page cursor)
+func (r *CommentRepo) GetAtOffset(ctx context.Context, storyUUID string, offset int64) (*domain.Comment, error) {
+	var comment domain.Comment
+	err := r.replica.Reader().WithContext(ctx).
+		Where("story_uuid = ?", storyUUID).
+		Scopes(publishedOnly).
+		Order("created_at desc, public_id desc").
+		Offset(int(offset)).
+		Take(&comment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
 // Update: Menyimpan perubahan konten komentar
 func (r *CommentRepo) Update(ctx context.Context, c *domain.Comment) error {
 	// Menggunakan Save agar UpdatedAt otomatis diperbarui oleh GORM
@@ -56,4 +332,132 @@ func (r *CommentRepo) Update(ctx context.Context, c *domain.Comment) error {
 // Delete: Menghapus komentar berdasarkan ID
 func (r *CommentRepo) Delete(ctx context.Context, id uint) error {
 	return r.db.WithContext(ctx).Delete(&domain.Comment{}, id).Error
-}
\ No newline at end of file
+}
+
+// ArchiveByStory: Memindahkan satu batch komentar story ke comments_archive dalam satu statement
+// (DELETE ... RETURNING -> INSERT), return jumlah yang dipindah. 0 = story sudah kosong.
+// FOR UPDATE tanpa SKIP LOCKED: baris yang sedang diedit ditunggu, bukan dilewati, agar 0 benar-benar
+// berarti kosong. Tanpa ON CONFLICT: id yang sudah ada di arsip menggagalkan seluruh statement
+// (DELETE ikut batal) daripada komentar terhapus tanpa pernah masuk arsip
+func (r *CommentRepo) ArchiveByStory(ctx context.Context, storyUUID, reason string, batchSize int) (int64, error) {
+	res := r.db.WithContext(ctx).Exec(`
+		WITH batch AS (
+			SELECT id FROM comments WHERE story_uuid = ? ORDER BY id LIMIT ? FOR UPDATE
+		), moved AS (
+			DELETE FROM comments c USING batch WHERE c.id = batch.id
+			RETURNING c.id, c.public_id, c.story_uuid, c.user_id, c.content, c.content_html, c.status, c.created_at, c.updated_at
+		)
+		INSERT INTO comments_archive (id, public_id, story_uuid, user_id, content, content_html, status, created_at, updated_at, archive_reason)
+		SELECT id, public_id, story_uuid, user_id, content, content_html, status, created_at, updated_at, ? FROM moved`,
+		storyUUID, batchSize, reason)
+	return res.RowsAffected, res.Error
+}
+
+// ListForExport: Semua komentar user (semua status) dari primary, keyset berdasarkan id
+func (r *CommentRepo) ListForExport(ctx context.Context, userID string, afterID uint, limit int) ([]domain.Comment, error) {
+	var comments []domain.Comment
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND id > ?", userID, afterID).
+		Order("id asc").Limit(limit).
+		Find(&comments).Error
+	return comments, err
+}
+
+// ListArchivedForExport: Sama seperti ListForExport untuk tabel comments_archive
+func (r *CommentRepo) ListArchivedForExport(ctx context.Context, userID string, afterID uint, limit int) ([]domain.ArchivedComment, error) {
+	var comments []domain.ArchivedComment
+	err := r.db.WithContext(ctx).Table("comments_archive").
+		Where("user_id = ? AND id > ?", userID, afterID).
+		Order("id asc").Limit(limit).
+		Find(&comments).Error
+	return comments, err
+}
+
+// ListForRender: Batch komentar untuk (re)render content_html, keyset berdasarkan id
+func (r *CommentRepo) ListForRender(ctx context.Context, afterID uint, limit int, all bool) ([]domain.Comment, error) {
+	tx := r.db.WithContext(ctx).Select("id", "content").Where("id > ?", afterID)
+	if !all {
+		tx = tx.Where("content_html = ''")
+	}
+	var comments []domain.Comment
+	err := tx.Order("id asc").Limit(limit).Find(&comments).Error
+	return comments, err
+}
+
+// UpdateContentHTML: Bukan edit dari user. UpdateColumn mencegah GORM mengisi updated_at, dan trigger
+// update_comments_modtime / comments_stats_update (migrasi 012) dilewati karena hanya content_html yang berubah,
+// jadi updated_at komentar & Last-Modified story tetap
+func (r *CommentRepo) UpdateContentHTML(ctx context.Context, id uint, html string) error {
+	return r.db.WithContext(ctx).Model(&domain.Comment{}).Where("id = ?", id).UpdateColumn("content_html", html).Error
+}
+
+// EraseByUser: Anonimkan / hapus satu batch komentar user, return story_uuid tiap baris yang terkena
+// (boleh duplikat). Slice kosong = tidak ada lagi komentar user ini, karena baris yang sedang
+// dikunci transaksi lain ditunggu (FOR UPDATE tanpa SKIP LOCKED), bukan dilewati.
+// Trigger story_comment_stats ikut menyesuaikan jumlah saat komentar dihapus
+func (r *CommentRepo) EraseByUser(ctx context.Context, userID, policy string, batchSize int) ([]string, error) {
+	batch := `SELECT id FROM comments WHERE user_id = ? ORDER BY id LIMIT ? FOR UPDATE`
+
+	var stories []string
+	var err error
+	switch policy {
+	case domain.ErasurePolicyDelete:
+		err = r.db.WithContext(ctx).Raw(
+			`DELETE FROM comments WHERE id IN (`+batch+`) RETURNING story_uuid`,
+			userID, batchSize).Scan(&stories).Error
+	case domain.ErasurePolicyAnonymize:
+		err = r.db.WithContext(ctx).Raw(
+			`UPDATE comments SET user_id = ? WHERE id IN (`+batch+`) RETURNING story_uuid`,
+			domain.ErasedUserID, userID, batchSize).Scan(&stories).Error
+	default:
+		return nil, domain.ErrBadParamInput
+	}
+	return stories, err
+}
+
+// EraseArchivedByUser: Arsip tidak pernah di-cache & tidak dihitung di stats, cukup satu statement
+func (r *CommentRepo) EraseArchivedByUser(ctx context.Context, userID, policy string) (int64, error) {
+	var res *gorm.DB
+	switch policy {
+	case domain.ErasurePolicyDelete:
+		res = r.db.WithContext(ctx).Exec(`DELETE FROM comments_archive WHERE user_id = ?`, userID)
+	case domain.ErasurePolicyAnonymize:
+		res = r.db.WithContext(ctx).Exec(`UPDATE comments_archive SET user_id = ? WHERE user_id = ?`, domain.ErasedUserID, userID)
+	default:
+		return 0, domain.ErrBadParamInput
+	}
+	return res.RowsAffected, res.Error
+}
+
+// ResetStoryStats: Jumlah story yang sudah diarsipkan dinolkan, barisnya tetap disimpan karena
+// updated_at adalah Last-Modified yang monoton. Jika dihapus, komentar yang dipulihkan nanti
+// memulai updated_at baru yang bisa lebih lama dari If-Modified-Since client (304 palsu)
+func (r *CommentRepo) ResetStoryStats(ctx context.Context, storyUUID string) error {
+	return r.db.WithContext(ctx).
+		Exec(`UPDATE story_comment_stats SET comment_count = 0, updated_at = GREATEST(updated_at, NOW())
+			WHERE story_uuid = ? AND comment_count <> 0`, storyUUID).Error
+}
+// --- SCOPES ---
+
+// publishedOnly: Hanya komentar yang tampil ke publik
+func publishedOnly(tx *gorm.DB) *gorm.DB {
+	return tx.Where("status = ?", domain.CommentStatusPublished)
+}
+
+// paginate: Keyset pagination berdasarkan (created_at, public_id) sesuai arah sort
+func paginate(query domain.CommentQuery) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		if query.Sort == domain.SortOldest {
+			if query.After != nil {
+				tx = tx.Where("(created_at, public_id) > (?, ?)", query.After.CreatedAt, query.After.ID)
+			}
+			return tx.Order("created_at asc, public_id asc").Limit(query.Limit + 1)
+		}
+
+		// Default: terbaru di atas agar user langsung melihat komentar baru
+		if query.After != nil {
+			tx = tx.Where("(created_at, public_id) < (?, ?)", query.After.CreatedAt, query.After.ID)
+		}
+		return tx.Order("created_at desc, public_id desc").Limit(query.Limit + 1)
+	}
+}
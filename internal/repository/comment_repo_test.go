@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"khalif-comment/internal/domain"
+	"khalif-comment/pkg/database"
+
+)
+
+// testDB: Test repository butuh Postgres sungguhan (trigger & migrasi), dilewati jika TEST_DATABASE_URL kosong.
+// Jangan arahkan ke database production, migrasi dijalankan di sini
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestUpdateContentHTMLKeepsUpdatedAt(t *testing.T) {
+	db := testDB(t)
+	repo := NewCommentRepository(db, database.NewReplica(db, nil, 0))
+	ctx := context.Background()
+
+	comment := &domain.Comment{
+		PublicID:  ulid.Make().String(),
+		StoryUUID: "test-" + ulid.Make().String(),
+		UserID:    "user-1",
+		Content:   "**halo**",
+		Status:    domain.CommentStatusPublished,
+	}
+	if err := repo.Create(ctx, comment); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM comments WHERE id = ?", comment.ID)
+		db.Exec("DELETE FROM story_comment_stats WHERE story_uuid = ?", comment.StoryUUID)
+	})
+
+	before, err := repo.GetByID(ctx, comment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statsBefore, err := repo.GetStoryStats(ctx, comment.StoryUUID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pastikan NOW() di transaksi berikutnya pasti berbeda jika trigger ikut jalan
+	time.Sleep(10 * time.Millisecond)
+	if err := repo.UpdateContentHTML(ctx, comment.ID, "<p><strong>halo</strong></p>"); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := repo.GetByID(ctx, comment.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.ContentHTML != "<p><strong>halo</strong></p>" {
+		t.Fatalf("content_html not updated: %q", after.ContentHTML)
+	}
+	if !after.UpdatedAt.Equal(before.UpdatedAt) {
+		t.Errorf("comment updated_at changed: %v -> %v", before.UpdatedAt, after.UpdatedAt)
+	}
+
+	statsAfter, err := repo.GetStoryStats(ctx, comment.StoryUUID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !statsAfter.LastUpdated.Equal(statsBefore.LastUpdated) {
+		t.Errorf("story last_updated changed: %v -> %v", statsBefore.LastUpdated, statsAfter.LastUpdated)
+	}
+}
+
+func TestArchiveKeepsStoryLastUpdated(t *testing.T) {
+	db := testDB(t)
+	repo := NewCommentRepository(db, database.NewReplica(db, nil, 0))
+	ctx := context.Background()
+
+	storyUUID := "test-" + ulid.Make().String()
+	comment := &domain.Comment{
+		PublicID:  ulid.Make().String(),
+		StoryUUID: storyUUID,
+		UserID:    "user-1",
+		Content:   "halo",
+		Status:    domain.CommentStatusPublished,
+	}
+	if err := repo.Create(ctx, comment); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM comments_archive WHERE story_uuid = ?", storyUUID)
+		db.Exec("DELETE FROM story_comment_stats WHERE story_uuid = ?", storyUUID)
+	})
+
+	before, err := repo.GetStoryStats(ctx, storyUUID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.ArchiveByStory(ctx, storyUUID, "test", 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ResetStoryStats(ctx, storyUUID); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := repo.GetStoryStats(ctx, storyUUID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Count != 0 {
+		t.Errorf("count = %d, want 0", after.Count)
+	}
+	if after.LastUpdated.IsZero() || after.LastUpdated.Before(before.LastUpdated) {
+		t.Errorf("last_updated went back: %v -> %v", before.LastUpdated, after.LastUpdated)
+	}
+
+	counts, err := repo.GetCounts(ctx, []string{storyUUID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts[storyUUID] != 0 {
+		t.Errorf("stats count = %d, want 0", counts[storyUUID])
+	}
+}
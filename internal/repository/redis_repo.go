@@ -5,43 +5,201 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"khalif-comment/pkg/health"
+	"khalif-comment/pkg/logger"
 
 )
 
+// RedisRepo: Semua operasi melewati circuit breaker. Selama Redis down, operasi langsung
+// gagal dengan health.ErrRedisUnavailable tanpa menunggu timeout jaringan
 type RedisRepo struct {
 	client *redis.Client
+	health *health.RedisMonitor // nil = tanpa breaker (CLI)
 }
 
 // NewCacheRepository membuat instance RedisRepo
 // Fungsi ini akan dipanggil oleh Wire di cmd/api/wire.go
-func NewCacheRepository(client *redis.Client) *RedisRepo {
-	return &RedisRepo{client: client}
+func NewCacheRepository(client *redis.Client, monitor *health.RedisMonitor) *RedisRepo {
+	return &RedisRepo{client: client, health: monitor}
 }
 
 // Get mengambil value berdasarkan key
 func (r *RedisRepo) Get(ctx context.Context, key string) (string, error) {
-	return r.client.Get(ctx, key).Result()
+	if !r.health.Available() {
+		return "", health.ErrRedisUnavailable
+	}
+	val, err := r.client.Get(ctx, key).Result()
+	r.health.Report(err)
+	return val, err
+}
+
+// MGet mengambil banyak key dalam satu round trip, key yang tidak ada bernilai nil
+func (r *RedisRepo) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	if !r.health.Available() {
+		return nil, health.ErrRedisUnavailable
+	}
+	vals, err := r.client.MGet(ctx, keys...).Result()
+	r.health.Report(err)
+	return vals, err
 }
 
 // Set menyimpan value dengan durasi (TTL) tertentu
 func (r *RedisRepo) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
-	return r.client.Set(ctx, key, value, ttl).Err()
+	if !r.health.Available() {
+		return health.ErrRedisUnavailable
+	}
+	err := r.client.Set(ctx, key, value, ttl).Err()
+	r.health.Report(err)
+	return err
+}
+
+// SetNX menyimpan value hanya jika key belum ada, return false jika key sudah ada (dipakai sebagai lock)
+func (r *RedisRepo) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	if !r.health.Available() {
+		return false, health.ErrRedisUnavailable
+	}
+	ok, err := r.client.SetNX(ctx, key, value, ttl).Result()
+	r.health.Report(err)
+	return ok, err
+}
+
+// SetMany menyimpan banyak key dengan TTL yang sama dalam satu pipeline
+func (r *RedisRepo) SetMany(ctx context.Context, values map[string]interface{}, ttl time.Duration) error {
+	if !r.health.Available() {
+		return health.ErrRedisUnavailable
+	}
+	pipe := r.client.Pipeline()
+	for key, value := range values {
+		pipe.Set(ctx, key, value, ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	r.health.Report(err)
+	return err
 }
 
 // Del menghapus satu key
 func (r *RedisRepo) Del(ctx context.Context, key string) error {
-	return r.client.Del(ctx, key).Err()
+	if !r.health.Available() {
+		return health.ErrRedisUnavailable
+	}
+	err := r.client.Del(ctx, key).Err()
+	r.health.Report(err)
+	return err
+}
+
+// Publish mengirim pesan ke semua subscriber channel
+func (r *RedisRepo) Publish(ctx context.Context, channel, message string) error {
+	if !r.health.Available() {
+		return health.ErrRedisUnavailable
+	}
+	err := r.client.Publish(ctx, channel, message).Err()
+	r.health.Report(err)
+	return err
+}
+
+// Subscribe menerima pesan channel sampai ctx dibatalkan.
+// go-redis otomatis reconnect & subscribe ulang, onSubscribe dipanggil setiap kali itu terjadi
+func (r *RedisRepo) Subscribe(ctx context.Context, channel string, onMessage func(payload string), onSubscribe func()) error {
+	sub := r.client.Subscribe(ctx, channel)
+	defer sub.Close()
+
+	for {
+		msg, err := sub.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			logger.Error("Redis subscription error, retrying", zap.String("channel", channel), zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		switch m := msg.(type) {
+		case *redis.Subscription:
+			if m.Kind == "subscribe" && onSubscribe != nil {
+				onSubscribe()
+			}
+		case *redis.Message:
+			onMessage(m.Payload)
+		}
+	}
+}
+
+// Incr menaikkan counter dan mengembalikan nilai barunya (key baru dimulai dari 0)
+func (r *RedisRepo) Incr(ctx context.Context, key string) (int64, error) {
+	if !r.health.Available() {
+		return 0, health.ErrRedisUnavailable
+	}
+	n, err := r.client.Incr(ctx, key).Result()
+	r.health.Report(err)
+	return n, err
+}
+
+// Expire memasang TTL pada key yang sudah ada
+func (r *RedisRepo) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	if !r.health.Available() {
+		return health.ErrRedisUnavailable
+	}
+	err := r.client.Expire(ctx, key, ttl).Err()
+	r.health.Report(err)
+	return err
+}
+
+// Panjang maksimal stream event keluar (approximate trim), consumer yang tertinggal lebih jauh kehilangan event lama
+const appendStreamMaxLen = 100000
+
+// AppendStream menambahkan satu event ke Redis Stream (XADD)
+func (r *RedisRepo) AppendStream(ctx context.Context, stream string, values map[string]interface{}) error {
+	if !r.health.Available() {
+		return health.ErrRedisUnavailable
+	}
+	err := r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: appendStreamMaxLen,
+		Approx: true,
+		Values: values,
+	}).Err()
+	r.health.Report(err)
+	return err
 }
 
-// DeletePrefix menghapus semua key yang diawali dengan prefix tertentu
-// Berguna untuk invalidasi cache, misal: r.DeletePrefix(ctx, "comments:")
+// Jumlah key per iterasi SCAN. Kecil agar setiap SCAN tidak memblokir Redis terlalu lama
+const deletePrefixScanCount = 500
+
+// DeletePrefix menghapus semua key yang diawali dengan prefix tertentu, untuk flush manual oleh admin.
+// Invalidasi rutin memakai counter generasi (Incr), bukan fungsi ini.
+// SCAN bertahap + UNLINK (hapus di background thread Redis) yang dikirim per batch dalam satu pipeline
 func (r *RedisRepo) DeletePrefix(ctx context.Context, prefix string) error {
-	iter := r.client.Scan(ctx, 0, prefix+"*", 0).Iterator()
-	for iter.Next(ctx) {
-		r.client.Del(ctx, iter.Val())
+	if !r.health.Available() {
+		return health.ErrRedisUnavailable
 	}
-	if err := iter.Err(); err != nil {
-		return err
+
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, prefix+"*", deletePrefixScanCount).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			pipe := r.client.Pipeline()
+			for _, key := range keys {
+				pipe.Unlink(ctx, key)
+			}
+			if _, err := pipe.Exec(ctx); err != nil {
+				return err
+			}
+		}
+
+		if next == 0 {
+			return nil
+		}
+		cursor = next
 	}
-	return nil
 }
\ No newline at end of file
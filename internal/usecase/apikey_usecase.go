@@ -0,0 +1,266 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"khalif-comment/internal/domain"
+	"khalif-comment/pkg/cache"
+	"khalif-comment/pkg/logger"
+
+)
+
+const (
+	apiKeyPrefix      = "kck_"
+	apiKeyPrefixBytes = 6  // 12 karakter hex, bagian publik
+	apiKeySecretBytes = 32 // 64 karakter hex
+
+	// Key dicabut lewat CLI berlaku paling lambat setelah apiKeyLocalTTL di instance lain
+	apiKeyCacheTTL  = 5 * time.Minute
+	apiKeyLocalTTL  = 30 * time.Second
+	apiKeyLocalSize = 1000
+
+	// last_used_at cukup akurat per menit, jangan UPDATE setiap request
+	apiKeyTouchInterval = time.Minute
+)
+
+// cachedAPIKey: Bentuk key di cache (Redis & L1). Terpisah dari domain.APIKey karena
+// json tag domain menyembunyikan key_hash. KeyHash kosong = prefix tidak ada (negative cache)
+type cachedAPIKey struct {
+	ID        uint       `json:"id"`
+	Prefix    string     `json:"prefix"`
+	KeyHash   string     `json:"key_hash"`
+	Service   string     `json:"service"`
+	Scopes    []string   `json:"scopes"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type APIKeyUC struct {
+	apiKeyRepo domain.APIKeyRepository
+	redisRepo  domain.RedisRepository
+	local      *cache.Local
+
+	touchMu   sync.Mutex
+	lastTouch map[uint]time.Time
+}
+
+func NewAPIKeyUseCase(repo domain.APIKeyRepository, redis domain.RedisRepository) *APIKeyUC {
+	return &APIKeyUC{
+		apiKeyRepo: repo,
+		redisRepo:  redis,
+		local:      cache.NewLocal(apiKeyLocalSize, apiKeyLocalTTL),
+		lastTouch:  map[uint]time.Time{},
+	}
+}
+
+func apiKeyCacheKey(prefix string) string {
+	return "api_key:" + prefix
+}
+
+func hashAPIKey(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create: Membuat key baru untuk satu service
+func (uc *APIKeyUC) Create(ctx context.Context, service string, scopes []string) (*domain.APIKey, string, error) {
+	service = strings.TrimSpace(service)
+	if service == "" {
+		return nil, "", domain.ErrEmptyServiceName
+	}
+
+	unique := make([]string, 0, len(scopes))
+	seen := map[string]bool{}
+	for _, s := range scopes {
+		s = strings.TrimSpace(s)
+		if !domain.IsValidScope(s) {
+			return nil, "", domain.ErrInvalidScope
+		}
+		if !seen[s] {
+			seen[s] = true
+			unique = append(unique, s)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, "", domain.ErrInvalidScope
+	}
+
+	prefix, err := randomHex(apiKeyPrefixBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(apiKeySecretBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext := apiKeyPrefix + prefix + "_" + secret
+
+	key := &domain.APIKey{
+		Prefix:  prefix,
+		KeyHash: hashAPIKey(plaintext),
+		Service: service,
+		Scopes:  unique,
+	}
+	if err := uc.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	// Hapus negative cache jika prefix ini pernah dicoba sebelumnya
+	uc.forget(ctx, prefix)
+	return key, plaintext, nil
+}
+
+func (uc *APIKeyUC) List(ctx context.Context) ([]domain.APIKey, error) {
+	return uc.apiKeyRepo.List(ctx)
+}
+
+func (uc *APIKeyUC) Revoke(ctx context.Context, prefix string) error {
+	if err := uc.apiKeyRepo.Revoke(ctx, prefix); err != nil {
+		return err
+	}
+	uc.forget(ctx, prefix)
+	return nil
+}
+
+// Authenticate: Error apapun (format salah, prefix tidak ada, hash beda, dicabut) dilaporkan
+// sebagai ErrInvalidAPIKey agar pemanggil tidak bisa membedakan penyebabnya
+func (uc *APIKeyUC) Authenticate(ctx context.Context, plaintext string) (*domain.APIKey, error) {
+	prefix, ok := parseAPIKey(plaintext)
+	if !ok {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	cached, err := uc.lookup(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if cached.KeyHash == "" || cached.RevokedAt != nil ||
+		subtle.ConstantTimeCompare([]byte(cached.KeyHash), []byte(hashAPIKey(plaintext))) != 1 {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	uc.touch(cached.ID)
+	return &domain.APIKey{
+		ID:      cached.ID,
+		Prefix:  cached.Prefix,
+		Service: cached.Service,
+		Scopes:  cached.Scopes,
+	}, nil
+}
+
+// parseAPIKey: Format "kck_<12 hex>_<64 hex>". Dicek sebelum lookup apapun, agar key acak
+// tidak mengisi negative cache L1 atau memicu query DB
+func parseAPIKey(plaintext string) (string, bool) {
+	rest, ok := strings.CutPrefix(plaintext, apiKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != apiKeyPrefixBytes*2 || len(secret) != apiKeySecretBytes*2 {
+		return "", false
+	}
+	if !isLowerHex(prefix) || !isLowerHex(secret) {
+		return "", false
+	}
+	return prefix, true
+}
+
+// lookup: L1 -> Redis -> Postgres. Prefix yang tidak ada disimpan di L1 saja sebagai negative cache
+func (uc *APIKeyUC) lookup(ctx context.Context, prefix string) (*cachedAPIKey, error) {
+	cacheKey := apiKeyCacheKey(prefix)
+	if value, ok := uc.local.Get(cacheKey); ok {
+		return value.(*cachedAPIKey), nil
+	}
+
+	if raw, err := uc.redisRepo.Get(ctx, cacheKey); err == nil {
+		var cached cachedAPIKey
+		if json.Unmarshal([]byte(raw), &cached) == nil {
+			cache.Hit(cache.TierRedis)
+			uc.local.Set(cacheKey, &cached)
+			return &cached, nil
+		}
+	}
+	cache.Miss(cache.TierRedis)
+
+	key, err := uc.apiKeyRepo.GetByPrefix(ctx, prefix)
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		missing := &cachedAPIKey{Prefix: prefix}
+		uc.local.Set(cacheKey, missing)
+		return missing, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cached := &cachedAPIKey{
+		ID:        key.ID,
+		Prefix:    key.Prefix,
+		KeyHash:   key.KeyHash,
+		Service:   key.Service,
+		Scopes:    key.Scopes,
+		RevokedAt: key.RevokedAt,
+	}
+	if data, err := json.Marshal(cached); err == nil {
+		if err := uc.redisRepo.Set(ctx, cacheKey, data, apiKeyCacheTTL); err != nil {
+			logger.Error("Failed to cache api key", zap.String("prefix", prefix), zap.Error(err))
+		}
+	}
+	uc.local.Set(cacheKey, cached)
+	return cached, nil
+}
+
+func (uc *APIKeyUC) forget(ctx context.Context, prefix string) {
+	uc.local.Remove(apiKeyCacheKey(prefix))
+	if err := uc.redisRepo.Del(ctx, apiKeyCacheKey(prefix)); err != nil {
+		logger.Error("Failed to evict api key cache", zap.String("prefix", prefix), zap.Error(err))
+	}
+}
+
+// touch memperbarui last_used_at di background, maksimal sekali per apiKeyTouchInterval per key
+func (uc *APIKeyUC) touch(id uint) {
+	now := time.Now()
+	uc.touchMu.Lock()
+	if now.Sub(uc.lastTouch[id]) < apiKeyTouchInterval {
+		uc.touchMu.Unlock()
+		return
+	}
+	uc.lastTouch[id] = now
+	uc.touchMu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := uc.apiKeyRepo.TouchLastUsed(ctx, id, now); err != nil {
+			logger.Error("Failed to update api key last_used_at", zap.Uint("id", id), zap.Error(err))
+		}
+	}()
+}
+
+// isLowerHex: Sesuai output hex.EncodeToString, huruf besar ditolak agar satu key hanya punya satu bentuk
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+
+)
+
+func TestParseAPIKey(t *testing.T) {
+	prefix := "0123456789ab"
+	secret := strings.Repeat("0123456789abcdef", 4)
+
+	cases := []struct {
+		name       string
+		key        string
+		wantPrefix string
+		wantOK     bool
+	}{
+		{"valid", "kck_" + prefix + "_" + secret, prefix, true},
+		{"empty", "", "", false},
+		{"no kck prefix", prefix + "_" + secret, "", false},
+		{"wrong kck prefix", "kcx_" + prefix + "_" + secret, "", false},
+		{"uppercase kck", "KCK_" + prefix + "_" + secret, "", false},
+		{"no separator", "kck_" + prefix + secret, "", false},
+		{"prefix too short", "kck_" + prefix[1:] + "_" + secret, "", false},
+		{"prefix too long", "kck_" + prefix + "c_" + secret, "", false},
+		{"secret too short", "kck_" + prefix + "_" + secret[1:], "", false},
+		{"secret too long", "kck_" + prefix + "_" + secret + "0", "", false},
+		{"uppercase hex prefix", "kck_0123456789AB_" + secret, "", false},
+		{"uppercase hex secret", "kck_" + prefix + "_" + strings.ToUpper(secret), "", false},
+		{"non hex prefix", "kck_0123456789zz_" + secret, "", false},
+		{"non hex secret", "kck_" + prefix + "_" + secret[:63] + "g", "", false},
+		{"extra separator in secret", "kck_" + prefix + "_" + secret[:32] + "_" + secret[33:], "", false},
+		{"trailing newline", "kck_" + prefix + "_" + secret + "\n", "", false},
+		{"multibyte", "kck_" + prefix + "_" + secret[:62] + "\u00e9", "", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := parseAPIKey(tc.key)
+			if got != tc.wantPrefix || ok != tc.wantOK {
+				t.Errorf("parseAPIKey(%q) = %q, %v; want %q, %v", tc.key, got, ok, tc.wantPrefix, tc.wantOK)
+			}
+		})
+	}
+}
+
+// Key buatan Create harus selalu lolos parseAPIKey
+func TestGeneratedKeyFormat(t *testing.T) {
+	prefix, err := randomHex(apiKeyPrefixBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := randomHex(apiKeySecretBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := parseAPIKey(apiKeyPrefix + prefix + "_" + secret)
+	if !ok || got != prefix {
+		t.Fatalf("parseAPIKey(generated) = %q, %v; want %q, true", got, ok, prefix)
+	}
+}
@@ -0,0 +1,153 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"khalif-comment/internal/config"
+	"khalif-comment/internal/domain"
+	"khalif-comment/pkg/logger"
+	"khalif-comment/pkg/requestctx"
+
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 200
+
+	// Baris per DELETE saat retensi, menjaga transaksi tetap pendek
+	auditRetentionBatchSize = 1000
+)
+
+// audit mencatat satu aksi beserta pelaku dari requestctx (user JWT, service API key, atau system).
+// Dipanggil setelah aksi berhasil. Gagal mencatat tidak membatalkan aksi, tapi event lengkap
+// ditulis ke log error supaya masih bisa ditelusuri
+func (uc *CommentUC) audit(ctx context.Context, action, targetType, targetID, storyUUID string, before, after any) {
+	if uc.auditRepo == nil {
+		return
+	}
+
+	info := requestctx.From(ctx)
+	event := &domain.AuditEvent{
+		ActorType:  info.ActorType,
+		ActorID:    info.ActorID,
+		ActorRole:  info.ActorRole,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		StoryUUID:  storyUUID,
+		Before:     auditSnapshot(before),
+		After:      auditSnapshot(after),
+		IP:         info.IP,
+		RequestID:  info.RequestID,
+	}
+
+	// Aksi sudah terjadi, audit tetap ditulis walau client memutus request
+	if err := uc.auditRepo.Insert(context.WithoutCancel(ctx), event); err != nil {
+		logger.Error("Failed to write audit event",
+			zap.String("action", action),
+			zap.String("target_id", targetID),
+			zap.String("actor_type", event.ActorType),
+			zap.String("actor_id", event.ActorID),
+			zap.String("request_id", event.RequestID),
+			zap.ByteString("before", event.Before),
+			zap.ByteString("after", event.After),
+			zap.Error(err),
+		)
+	}
+}
+
+// auditSnapshot: nil tetap nil (kolom NULL), komentar diredaksi, selain itu di-encode JSON
+func auditSnapshot(v any) json.RawMessage {
+	switch c := v.(type) {
+	case nil:
+		return nil
+	case *domain.Comment:
+		if c == nil {
+			return nil
+		}
+		v = redactComment(c)
+	case domain.Comment:
+		v = redactComment(&c)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// redactComment: Format yang sama dipakai migrasi 013 untuk meredaksi baris audit lama
+func redactComment(c *domain.Comment) domain.AuditCommentSnapshot {
+	sum := sha256.Sum256([]byte(c.Content))
+	return domain.AuditCommentSnapshot{
+		ID:            c.PublicID,
+		StoryUUID:     c.StoryUUID,
+		Status:        c.Status,
+		ContentLength: utf8.RuneCountInString(c.Content),
+		ContentSHA256: hex.EncodeToString(sum[:]),
+		CreatedAt:     c.CreatedAt,
+		UpdatedAt:     c.UpdatedAt,
+	}
+}
+
+type AuditUC struct {
+	auditRepo domain.AuditRepository
+	retention time.Duration // 0 = simpan selamanya
+}
+
+func NewAuditUseCase(repo domain.AuditRepository, cfg *config.Config) *AuditUC {
+	return &AuditUC{auditRepo: repo, retention: cfg.AuditRetention}
+}
+
+// Query: Audit log terbaru di atas, cursor = id event terakhir di halaman sebelumnya
+func (uc *AuditUC) Query(ctx context.Context, query domain.AuditQuery) (*domain.AuditPage, error) {
+	if query.Limit <= 0 {
+		query.Limit = defaultAuditLimit
+	}
+	if query.Limit > maxAuditLimit {
+		query.Limit = maxAuditLimit
+	}
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		return nil, domain.ErrInvalidTimeRange
+	}
+
+	events, err := uc.auditRepo.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.AuditPage{Items: events}
+	if len(events) > query.Limit {
+		page.Items = events[:query.Limit]
+		page.NextCursor = strconv.FormatUint(uint64(page.Items[len(page.Items)-1].ID), 10)
+	}
+	return page, nil
+}
+
+// ApplyRetention: Hapus per batch sampai tidak ada lagi event yang melewati masa retensi
+func (uc *AuditUC) ApplyRetention(ctx context.Context) (int64, error) {
+	if uc.retention <= 0 {
+		return 0, nil
+	}
+
+	cutoff := time.Now().Add(-uc.retention)
+	var total int64
+	for {
+		n, err := uc.auditRepo.DeleteBefore(ctx, cutoff, auditRetentionBatchSize)
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n < auditRetentionBatchSize {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"khalif-comment/internal/domain"
+	"khalif-comment/pkg/cache"
+	"khalif-comment/pkg/logger"
+
+)
+
+const (
+	// Lock rebuild cache lintas instance. Harus lebih lama dari query DB terlama
+	cacheLockTTL = 5 * time.Second
+
+	// Instance yang kalah lock menunggu hasil dari pemegang lock sebelum query DB sendiri
+	cacheLockWaitAttempts = 10
+	cacheLockWaitInterval = 50 * time.Millisecond
+
+	// Batas waktu refresh background saat stale-while-revalidate
+	cacheRefreshTimeout = 10 * time.Second
+
+	// Channel pub/sub untuk evict L1 di semua instance, payload = story UUID
+	// atau cacheFlushAll untuk mengosongkan seluruh L1
+	cacheInvalidationChannel = "cache_invalidation:comments"
+	cacheFlushAll            = "*"
+
+	// Interval pengulangan invalidasi yang gagal karena Redis down
+	pendingInvalidationRetry = 5 * time.Second
+)
+
+// Prefix semua key cache komentar, untuk flush manual (counter generasi sengaja tidak ikut dihapus)
+var flushPrefixes = []string{"comments:", "comment_stats:", "comment_count:"}
+
+// cacheEntry: Envelope value di Redis. Disimpan lebih lama dari masa segarnya
+// supaya masih bisa disajikan (stale) selama satu worker membangun ulang
+type cacheEntry struct {
+	FreshUntil int64           `json:"fresh_until"` // Unix nano
+	Data       json.RawMessage `json:"data"`
+}
+
+// localEntry: Value di L1 beserta batas segarnya (mengikuti entry Redis asalnya)
+type localEntry struct {
+	Value      any
+	FreshUntil int64
+}
+
+// cacheSpec: Cara menyimpan satu jenis data di cache
+type cacheSpec struct {
+	Key        string        // Sudah memuat generasi story, lihat storyGeneration
+	StaleKey   string        // Opsional, key generasi sebelumnya yang boleh disajikan basi
+	TTL        time.Duration // Masa segar
+	AllowStale bool          // Sajikan data basi sambil refresh di background
+}
+
+// loadCached: Cache-aside dua tingkat (L1 in-process, L2 Redis) dengan proteksi stampede.
+//   - L1 hanya berisi data segar, entry basi selalu dilayani dari Redis
+//   - Dalam satu instance, request untuk key yang sama digabung lewat singleflight
+//   - Antar instance, hanya pemegang lock Redis yang query DB, sisanya menunggu hasilnya
+//   - Jika spec.AllowStale, entry yang kedaluwarsa atau milik generasi sebelumnya tetap
+//     dikembalikan (stale = true) sementara satu worker me-refresh di background
+func loadCached[T any](ctx context.Context, uc *CommentUC, spec cacheSpec, load func(ctx context.Context) (*T, error)) (value *T, stale bool, err error) {
+	if cached, ok := readLocal[T](uc, spec.Key); ok {
+		return cached, false, nil
+	}
+
+	if uc.redisRepo == nil {
+		v, err, _ := uc.flight.Do(spec.Key, func() (interface{}, error) {
+			storedAt := time.Now()
+			value, err := load(context.WithoutCancel(ctx))
+			if err == nil {
+				uc.local.Set(spec.Key, localEntry{Value: value, FreshUntil: storedAt.Add(spec.TTL).UnixNano()})
+			}
+			return value, err
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		return v.(*T), false, nil
+	}
+
+	if cached, fresh := readCached[T](ctx, uc, spec); cached != nil {
+		if fresh {
+			return cached, false, nil
+		}
+		if spec.AllowStale {
+			uc.refreshAsync(spec, func(ctx context.Context) (interface{}, error) {
+				return load(ctx)
+			})
+			return cached, true, nil
+		}
+	}
+
+	// Context request tidak diteruskan: jika request pertama dibatalkan, request lain yang ikut menunggu tidak ikut gagal
+	v, err, _ := uc.flight.Do(spec.Key, func() (interface{}, error) {
+		return rebuildCached[T](context.WithoutCancel(ctx), uc, spec, load)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return v.(*T), false, nil
+}
+
+// readLocal: Cek L1. Entry yang melewati masa segarnya dianggap miss
+func readLocal[T any](uc *CommentUC, key string) (*T, bool) {
+	cached, ok := uc.local.Get(key)
+	if !ok {
+		return nil, false
+	}
+	entry, ok := cached.(localEntry)
+	if !ok || time.Now().UnixNano() >= entry.FreshUntil {
+		return nil, false
+	}
+	value, ok := entry.Value.(*T)
+	return value, ok
+}
+
+// readCached: Ambil entry generasi sekarang (dan generasi sebelumnya jika boleh stale)
+// dalam satu round trip. Return nil jika miss. Entry segar sekalian disimpan ke L1
+func readCached[T any](ctx context.Context, uc *CommentUC, spec cacheSpec) (value *T, fresh bool) {
+	keys := []string{spec.Key}
+	if spec.AllowStale && spec.StaleKey != "" {
+		keys = append(keys, spec.StaleKey)
+	}
+
+	values, err := uc.redisRepo.MGet(ctx, keys...)
+	if err != nil || len(values) == 0 {
+		cache.Miss(cache.TierRedis)
+		return nil, false
+	}
+
+	if data, entry, ok := decodeEntry[T](values[0]); ok {
+		if time.Now().UnixNano() < entry.FreshUntil {
+			cache.Hit(cache.TierRedis)
+			uc.local.Set(spec.Key, localEntry{Value: data, FreshUntil: entry.FreshUntil})
+			return data, true
+		}
+		cache.Count("redis_stale")
+		return data, false
+	}
+
+	// Generasi sekarang belum dibangun, sajikan generasi sebelumnya
+	if len(values) > 1 {
+		if data, _, ok := decodeEntry[T](values[1]); ok {
+			cache.Count("redis_stale")
+			return data, false
+		}
+	}
+
+	cache.Miss(cache.TierRedis)
+	return nil, false
+}
+
+// decodeEntry: Format lama (tanpa envelope), key kosong atau data rusak dianggap miss
+func decodeEntry[T any](value interface{}) (*T, cacheEntry, bool) {
+	var entry cacheEntry
+	raw, ok := value.(string)
+	if !ok {
+		return nil, entry, false
+	}
+	if err := json.Unmarshal([]byte(raw), &entry); err != nil || len(entry.Data) == 0 {
+		return nil, entry, false
+	}
+	var data T
+	if err := json.Unmarshal(entry.Data, &data); err != nil {
+		return nil, entry, false
+	}
+	return &data, entry, true
+}
+
+// rebuildCached: Query DB & simpan ke cache. Hanya satu instance yang memegang lock,
+// instance lain menunggu sebentar lalu membaca hasilnya dari cache
+func rebuildCached[T any](ctx context.Context, uc *CommentUC, spec cacheSpec, load func(ctx context.Context) (*T, error)) (*T, error) {
+	locked, release := uc.acquireCacheLock(ctx, spec.Key)
+	if !locked {
+		for i := 0; i < cacheLockWaitAttempts; i++ {
+			time.Sleep(cacheLockWaitInterval)
+			if cached, fresh := readCached[T](ctx, uc, spec); cached != nil && fresh {
+				return cached, nil
+			}
+		}
+		// Pemegang lock terlalu lama (atau mati), query sendiri daripada request menggantung
+	}
+	defer release()
+
+	storedAt := time.Now()
+	value, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	uc.storeCached(ctx, spec, value, storedAt)
+	return value, nil
+}
+
+// refreshAsync: Refresh entry stale di background, maksimal satu worker per key di seluruh cluster
+func (uc *CommentUC) refreshAsync(spec cacheSpec, load func(ctx context.Context) (interface{}, error)) {
+	go func() {
+		_, _, _ = uc.flight.Do("refresh:"+spec.Key, func() (interface{}, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), cacheRefreshTimeout)
+			defer cancel()
+
+			locked, release := uc.acquireCacheLock(ctx, spec.Key)
+			if !locked {
+				return nil, nil // Instance lain sedang refresh
+			}
+			defer release()
+
+			storedAt := time.Now()
+			value, err := load(ctx)
+			if err != nil {
+				logger.Error("Failed to refresh stale cache entry", zap.String("key", spec.Key), zap.Error(err))
+				return nil, err
+			}
+			uc.storeCached(ctx, spec, value, storedAt)
+			return nil, nil
+		})
+	}()
+}
+
+// storeCached: Simpan envelope. Dengan stale-while-revalidate, key hidup TTL + staleTTL di Redis
+func (uc *CommentUC) storeCached(ctx context.Context, spec cacheSpec, value interface{}, storedAt time.Time) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	entry, err := json.Marshal(cacheEntry{
+		FreshUntil: storedAt.Add(spec.TTL).UnixNano(),
+		Data:       data,
+	})
+	if err != nil {
+		return
+	}
+
+	ttl := spec.TTL
+	if spec.AllowStale {
+		ttl += uc.staleTTL
+	}
+	if err := uc.redisRepo.Set(ctx, spec.Key, entry, ttl); err != nil {
+		return
+	}
+	uc.local.Set(spec.Key, localEntry{Value: value, FreshUntil: storedAt.Add(spec.TTL).UnixNano()})
+}
+
+// storyGeneration: Generasi cache story saat ini. Setiap invalidasi cukup INCR counter ini,
+// entry generasi lama tidak lagi dibaca (kecuali sebagai stale) dan hilang sendiri oleh TTL.
+// Counter di-cache di L1 dan di-evict lewat pub/sub bersama entry lainnya.
+// ok = false jika generasi tidak diketahui (Redis error), caller harus melewati cache
+// agar tidak membaca entry generasi lama sebagai data segar
+func (uc *CommentUC) storyGeneration(ctx context.Context, storyUUID string) (gen int64, ok bool) {
+	key := generationCacheKey(storyUUID)
+	if cached, found := uc.local.Get(key); found {
+		if gen, isGen := cached.(int64); isGen {
+			return gen, true
+		}
+	}
+	if uc.redisRepo == nil {
+		return 0, true
+	}
+
+	// MGET: key yang belum ada (story belum pernah di-invalidate) bernilai nil tanpa error
+	values, err := uc.redisRepo.MGet(ctx, key)
+	if err != nil || len(values) == 0 {
+		return 0, false
+	}
+	if raw, isString := values[0].(string); isString {
+		if gen, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return 0, false
+		}
+	}
+	uc.local.Set(key, gen)
+	return gen, true
+}
+
+// evictLocal: Hapus semua entry L1 milik sebuah story (generasi, list semua halaman & statistik)
+func (uc *CommentUC) evictLocal(storyUUID string) {
+	uc.local.Remove(generationCacheKey(storyUUID))
+	uc.local.RemovePrefix(fmt.Sprintf("comments:%s:", storyUUID))
+	uc.local.RemovePrefix(fmt.Sprintf("comment_stats:%s:", storyUUID))
+}
+
+// ListenInvalidations: Evict L1 setiap kali sebuah instance meng-invalidate story.
+// Saat (re)subscribe seluruh L1 dikosongkan karena pesan selama terputus mungkin terlewat.
+// Invalidasi yang gagal selama Redis down diulang secara berkala sampai berhasil
+func (uc *CommentUC) ListenInvalidations(ctx context.Context) error {
+	if uc.redisRepo == nil {
+		return nil
+	}
+
+	go func() {
+		ticker := time.NewTicker(pendingInvalidationRetry)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				uc.retryPendingInvalidations(ctx)
+			}
+		}
+	}()
+
+	return uc.redisRepo.Subscribe(ctx, cacheInvalidationChannel, func(storyUUID string) {
+		cache.Count("invalidations_received")
+		if storyUUID == cacheFlushAll {
+			uc.local.Purge()
+			return
+		}
+		uc.evictLocal(storyUUID)
+	}, uc.local.Purge)
+}
+
+// retryPendingInvalidations: Ulangi invalidasi story yang gagal selama Redis down
+func (uc *CommentUC) retryPendingInvalidations(ctx context.Context) {
+	uc.pendingMu.Lock()
+	pending := make([]string, 0, len(uc.pendingInvalidations))
+	for storyUUID := range uc.pendingInvalidations {
+		pending = append(pending, storyUUID)
+	}
+	uc.pendingMu.Unlock()
+
+	retried := 0
+	for _, storyUUID := range pending {
+		// Redis masih down, coba lagi di tick berikutnya
+		if err := uc.bumpGeneration(ctx, storyUUID); err != nil {
+			return
+		}
+		uc.pendingMu.Lock()
+		delete(uc.pendingInvalidations, storyUUID)
+		uc.pendingMu.Unlock()
+		retried++
+	}
+	if retried > 0 {
+		logger.Info("Replayed cache invalidations after Redis recovery", zap.Int("stories", retried))
+	}
+}
+
+// FlushCache menghapus semua cache komentar di Redis lalu mengosongkan L1 semua instance.
+// Hanya untuk operasi admin (misal setelah reset database), bukan invalidasi rutin
+func FlushCache(ctx context.Context, redisRepo domain.RedisRepository) error {
+	for _, prefix := range flushPrefixes {
+		if err := redisRepo.DeletePrefix(ctx, prefix); err != nil {
+			return fmt.Errorf("flush %s*: %w", prefix, err)
+		}
+	}
+	return redisRepo.Publish(ctx, cacheInvalidationChannel, cacheFlushAll)
+}
+
+// acquireCacheLock: SET NX dengan TTL. Jika Redis error, anggap lock didapat agar request tetap jalan
+func (uc *CommentUC) acquireCacheLock(ctx context.Context, key string) (bool, func()) {
+	lockKey := "lock:" + key
+
+	ok, err := uc.redisRepo.SetNX(ctx, lockKey, 1, cacheLockTTL)
+	if err != nil {
+		return true, func() {}
+	}
+	if !ok {
+		return false, func() {}
+	}
+	return true, func() {
+		// Lock punya TTL sendiri, jadi gagal hapus di sini hanya memperlambat rebuild berikutnya
+		_ = uc.redisRepo.Del(context.WithoutCancel(ctx), lockKey)
+	}
+}
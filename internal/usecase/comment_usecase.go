@@ -2,36 +2,107 @@ package usecase
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/oklog/ulid/v2"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+
+	"khalif-comment/internal/config"
 	"khalif-comment/internal/domain"
+	"khalif-comment/pkg/cache"
+	"khalif-comment/pkg/logger"
+	"khalif-comment/pkg/pagination"
+	"khalif-comment/pkg/textnorm"
+
+)
+
+const (
+	defaultContextSiblings = 3
+	maxContextSiblings     = 20
+
+	// Skor trigram minimal untuk dianggap duplikat / spam
+	defaultDuplicateThreshold = 0.6
+)
 
+// Jumlah komentar per statement saat mengarsipkan story, menjaga lock & WAL per transaksi tetap kecil
+const archiveBatchSize = 500
+
+const (
+	listCacheTTL  = 10 * time.Minute
+	statsCacheTTL = 10 * time.Minute
+	countCacheTTL = 5 * time.Minute
 )
 
 type CommentUC struct {
-	commentRepo domain.CommentRepository
-	redisRepo   domain.RedisRepository
+	commentRepo    domain.CommentRepository
+	redisRepo      domain.RedisRepository
+	auditRepo      domain.AuditRepository // nil = tanpa audit log
+	allowLegacyIDs bool
+
+	// Proteksi cache stampede & L1 in-process, lihat cache.go
+	local                *cache.Local
+	flight               singleflight.Group
+	staleWhileRevalidate bool
+	staleTTL             time.Duration
+
+	// Story yang gagal di-invalidate selama Redis down, diulang saat Redis kembali
+	pendingMu            sync.Mutex
+	pendingInvalidations map[string]struct{}
+
+	// Redis Stream tujuan event keluar (story.comments_archived, dst)
+	eventsStream string
+
+	// domain.ErasurePolicyAnonymize / domain.ErasurePolicyDelete
+	erasurePolicy string
+
+	// Normalisasi & batas panjang isi komentar (grapheme cluster), lihat content.go
+	normalizer *textnorm.Normalizer
+	minLength  int
+	maxLength  int
 }
 
-func NewCommentUseCase(repo domain.CommentRepository, redis domain.RedisRepository) *CommentUC {
+func NewCommentUseCase(repo domain.CommentRepository, redis domain.RedisRepository, audit domain.AuditRepository, cfg *config.Config) *CommentUC {
 	return &CommentUC{
-		commentRepo: repo,
-		redisRepo:   redis,
+		commentRepo:          repo,
+		redisRepo:            redis,
+		auditRepo:            audit,
+		allowLegacyIDs:       cfg.AllowLegacyIDs,
+		local:                cache.NewLocal(cfg.CacheLocalSize, cfg.CacheLocalTTL),
+		staleWhileRevalidate: cfg.CacheStaleWhileRevalidate,
+		staleTTL:             cfg.CacheStaleTTL,
+		pendingInvalidations: map[string]struct{}{},
+		eventsStream:         cfg.CommentEventsStream,
+		erasurePolicy:        cfg.ErasurePolicy,
+		normalizer: textnorm.New(textnorm.Options{
+			NFC:            cfg.ContentNFC,
+			StripInvisible: cfg.ContentStripInvisible,
+			Trim:           cfg.ContentTrim,
+			MaxNewlines:    cfg.ContentMaxNewlines,
+		}),
+		minLength: cfg.ContentMinLength,
+		maxLength: cfg.ContentMaxLength,
 	}
 }
 
 // Create: Menambahkan komentar baru
 func (uc *CommentUC) Create(ctx context.Context, storyUUID, userID, content string) (*domain.Comment, error) {
-	if content == "" {
-		return nil, domain.ErrEmptyContent
+	content, err := uc.normalizeContent(content)
+	if err != nil {
+		return nil, err
 	}
 
 	comment := &domain.Comment{
-		StoryUUID: storyUUID,
-		UserID:    userID,
-		Content:   content,
+		PublicID:    ulid.Make().String(),
+		StoryUUID:   storyUUID,
+		UserID:      userID,
+		Content:     content,
+		ContentHTML: renderContent(content),
+		Status:      domain.CommentStatusPublished,
 	}
 
 	if err := uc.commentRepo.Create(ctx, comment); err != nil {
@@ -39,53 +110,292 @@ func (uc *CommentUC) Create(ctx context.Context, storyUUID, userID, content stri
 	}
 
 	// Invalidate Cache untuk Story ini agar komentar baru muncul
-	if uc.redisRepo != nil {
-		cacheKey := fmt.Sprintf("comments:%s", storyUUID)
-		_ = uc.redisRepo.Del(ctx, cacheKey)
-	}
+	uc.invalidateStory(ctx, storyUUID)
+	uc.audit(ctx, domain.AuditCommentCreate, domain.AuditTargetComment, comment.PublicID, storyUUID, nil, comment)
 
 	return comment, nil
 }
 
-// GetByStoryUUID: Mengambil daftar komentar (dengan Caching)
-func (uc *CommentUC) GetByStoryUUID(ctx context.Context, storyUUID string) ([]domain.Comment, error) {
-	cacheKey := fmt.Sprintf("comments:%s", storyUUID)
+// GetByStoryUUID: Mengambil satu halaman komentar (dengan Caching per halaman).
+// Story yang ramai dilindungi dari stampede saat cache-nya di-invalidate, lihat loadCached
+func (uc *CommentUC) GetByStoryUUID(ctx context.Context, storyUUID string, query domain.CommentQuery) (*domain.CommentPage, error) {
+	query = normalizeQuery(query)
+	query.IncludeNonPublic = false // List story selalu publik (dan di-cache bersama)
 
-	// 1. Cek Redis
-	if uc.redisRepo != nil {
-		cachedData, err := uc.redisRepo.Get(ctx, cacheKey)
-		if err == nil && cachedData != "" {
-			var comments []domain.Comment
-			if err := json.Unmarshal([]byte(cachedData), &comments); err == nil {
-				return comments, nil
+	load := func(ctx context.Context, fresh bool) (*domain.CommentPage, error) {
+		query := query
+		query.Fresh = fresh
+		comments, err := uc.commentRepo.GetByStoryUUID(ctx, storyUUID, query)
+		if err != nil {
+			return nil, err
+		}
+		return buildPage(comments, query.Limit), nil
+	}
+
+	// Tanpa cache boleh dari replica, lag-nya hanya terlihat sampai REPLICA_MAX_LAG
+	gen, ok := uc.storyGeneration(ctx, storyUUID)
+	if !ok {
+		return load(ctx, false)
+	}
+
+	spec := cacheSpec{
+		Key:        listCacheKey(storyUUID, gen, query),
+		TTL:        listCacheTTL,
+		AllowStale: uc.staleWhileRevalidate,
+	}
+	if gen > 0 {
+		spec.StaleKey = listCacheKey(storyUUID, gen-1, query)
+	}
+
+	// Yang masuk cache dibaca dari primary: rebuild tepat setelah invalidasi tidak boleh
+	// menyimpan hasil replica yang belum memuat tulisan terbaru selama listCacheTTL
+	page, stale, err := loadCached(ctx, uc, spec, func(ctx context.Context) (*domain.CommentPage, error) {
+		return load(ctx, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if stale {
+		// Salin agar flag tidak bocor ke hasil singleflight yang dipakai request lain
+		copied := *page
+		copied.Stale = true
+		page = &copied
+	}
+	return page, nil
+}
+
+// GetCounts: Jumlah komentar untuk banyak story. Redis MGET dulu, sisanya dari story_comment_stats
+func (uc *CommentUC) GetCounts(ctx context.Context, storyUUIDs []string) (map[string]int64, error) {
+	counts := make(map[string]int64, len(storyUUIDs))
+	missing := make([]string, 0, len(storyUUIDs))
+
+	// 1. Cek Redis (satu round trip untuk semua story)
+	if uc.redisRepo != nil && len(storyUUIDs) > 0 {
+		keys := make([]string, len(storyUUIDs))
+		for i, id := range storyUUIDs {
+			keys[i] = countCacheKey(id)
+		}
+		if values, err := uc.redisRepo.MGet(ctx, keys...); err == nil {
+			for i, v := range values {
+				str, ok := v.(string)
+				if !ok {
+					continue
+				}
+				if n, err := strconv.ParseInt(str, 10, 64); err == nil {
+					counts[storyUUIDs[i]] = n
+				}
 			}
 		}
 	}
+	for _, id := range storyUUIDs {
+		if _, ok := counts[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return counts, nil
+	}
 
-	// 2. Ambil dari DB jika cache miss
-	comments, err := uc.commentRepo.GetByStoryUUID(ctx, storyUUID)
+	// 2. Ambil sisanya dari DB
+	fromDB, err := uc.commentRepo.GetCounts(ctx, missing)
 	if err != nil {
 		return nil, err
 	}
 
-	// 3. Simpan ke Redis (TTL 10 menit)
+	// 3. Simpan ke Redis (TTL 5 menit)
+	toCache := make(map[string]interface{}, len(fromDB))
+	for id, n := range fromDB {
+		counts[id] = n
+		toCache[countCacheKey(id)] = n
+	}
 	if uc.redisRepo != nil {
-		if data, err := json.Marshal(comments); err == nil {
-			_ = uc.redisRepo.Set(ctx, cacheKey, data, 10*time.Minute)
+		_ = uc.redisRepo.SetMany(ctx, toCache, countCacheTTL)
+	}
+
+	return counts, nil
+}
+
+// ReconcileCounts: Job berkala untuk memperbaiki drift story_comment_stats. Story yang diperbaiki
+// mendapat count & Last-Modified baru, jadi generasi cache-nya dinaikkan (list, stats & count)
+// agar ETag / Last-Modified di cache tidak tertinggal dari database
+func (uc *CommentUC) ReconcileCounts(ctx context.Context) (int, error) {
+	fixed, err := uc.commentRepo.ReconcileCounts(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, id := range fixed {
+		uc.invalidateStory(ctx, id)
+	}
+
+	return len(fixed), nil
+}
+
+// Search: Pencarian full-text. Moderator ikut mencari komentar yang tidak published
+func (uc *CommentUC) Search(ctx context.Context, viewer domain.Viewer, query domain.SearchQuery) (*domain.SearchPage, error) {
+	query.Query = strings.TrimSpace(query.Query)
+	if query.Query == "" {
+		return nil, domain.ErrEmptySearchQuery
+	}
+	query.Limit = pagination.NormalizeLimit(query.Limit)
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	query.IncludeNonPublic = viewer.IsModerator()
+
+	var results []domain.SearchResult
+	var err error
+	switch query.Mode {
+	case "", domain.SearchModeFullText:
+		results, err = uc.commentRepo.Search(ctx, query)
+	case domain.SearchModeFuzzy:
+		results, err = uc.commentRepo.FuzzySearch(ctx, query)
+	default:
+		return nil, domain.ErrInvalidSearchMode
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		ensureContentHTML(&results[i].Comment)
+	}
+
+	page := &domain.SearchPage{Items: results}
+	if len(results) > query.Limit {
+		page.Items = results[:query.Limit]
+		page.HasMore = true
+	}
+	return page, nil
+}
+
+// FindDuplicates: Mencari komentar yang mirip (trigram similarity) di semua story
+func (uc *CommentUC) FindDuplicates(ctx context.Context, id string, threshold float64, limit int) ([]domain.SearchResult, error) {
+	if threshold <= 0 {
+		threshold = defaultDuplicateThreshold
+	}
+	if threshold > 1 {
+		return nil, domain.ErrInvalidThreshold
+	}
+	limit = pagination.NormalizeLimit(limit)
+
+	comment, err := uc.findComment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := uc.commentRepo.FindSimilar(ctx, comment, threshold, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		ensureContentHTML(&results[i].Comment)
+	}
+	return results, nil
+}
+
+// GetByUserID: Mengambil komentar milik user untuk halaman profil.
+// Komentar pending / hidden / shadow-banned hanya ikut jika viewer adalah pemilik atau moderator
+func (uc *CommentUC) GetByUserID(ctx context.Context, userID string, viewer domain.Viewer, query domain.CommentQuery) (*domain.CommentPage, error) {
+	query = normalizeQuery(query)
+	query.IncludeNonPublic = viewer.UserID == userID || viewer.IsModerator()
+
+	comments, err := uc.commentRepo.GetByUserID(ctx, userID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildPage(comments, query.Limit), nil
+}
+
+// GetStoryStats: Mengambil jumlah & waktu update terakhir komentar sebuah story (dengan Caching).
+// Tidak pernah disajikan stale karena menjadi dasar ETag
+func (uc *CommentUC) GetStoryStats(ctx context.Context, storyUUID string) (*domain.StoryStats, error) {
+	gen, ok := uc.storyGeneration(ctx, storyUUID)
+	if !ok {
+		return uc.commentRepo.GetStoryStats(ctx, storyUUID, false)
+	}
+
+	spec := cacheSpec{
+		Key: statsCacheKey(storyUUID, gen),
+		TTL: statsCacheTTL,
+	}
+
+	// Dari primary, alasannya sama dengan GetByStoryUUID (ETag basi = 304 palsu)
+	stats, _, err := loadCached(ctx, uc, spec, func(ctx context.Context) (*domain.StoryStats, error) {
+		return uc.commentRepo.GetStoryStats(ctx, storyUUID, true)
+	})
+	return stats, err
+}
+
+// GetByID: Mengambil satu komentar. Komentar yang tidak boleh dilihat viewer dianggap tidak ada
+func (uc *CommentUC) GetByID(ctx context.Context, id string, viewer domain.Viewer) (*domain.Comment, error) {
+	comment, err := uc.findComment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !viewer.CanSee(comment) {
+		return nil, domain.ErrCommentNotFound
+	}
+	return comment, nil
+}
+
+// GetContext: Mengambil komentar beserta tetangganya & cursor halaman tempat komentar berada
+func (uc *CommentUC) GetContext(ctx context.Context, id string, viewer domain.Viewer, siblings, pageSize int) (*domain.CommentContext, error) {
+	if siblings <= 0 {
+		siblings = defaultContextSiblings
+	}
+	if siblings > maxContextSiblings {
+		siblings = maxContextSiblings
+	}
+	pageSize = pagination.NormalizeLimit(pageSize)
+
+	comment, err := uc.GetByID(ctx, id, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	newer, older, err := uc.commentRepo.GetSiblings(ctx, comment, siblings)
+	if err != nil {
+		return nil, err
+	}
+	ensureContentHTMLs(newer)
+	ensureContentHTMLs(older)
+
+	position, err := uc.commentRepo.CountNewer(ctx, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.CommentContext{
+		Comment:  *comment,
+		Newer:    newer,
+		Older:    older,
+		Position: position,
+	}
+
+	// Cursor halaman = komentar terakhir di halaman sebelumnya (sama seperti next_cursor di list)
+	page := position / int64(pageSize)
+	if page > 0 {
+		boundary, err := uc.commentRepo.GetAtOffset(ctx, comment.StoryUUID, page*int64(pageSize)-1)
+		if err != nil {
+			return nil, err
 		}
+		result.PageCursor = pagination.Cursor{CreatedAt: boundary.CreatedAt, ID: boundary.PublicID}.Encode()
 	}
 
-	return comments, nil
+	return result, nil
 }
 
 // Update: Mengubah isi komentar (Hanya Pemilik)
-func (uc *CommentUC) Update(ctx context.Context, id uint, userID, content string) (*domain.Comment, error) {
-	if content == "" {
-		return nil, domain.ErrEmptyContent
+func (uc *CommentUC) Update(ctx context.Context, id string, userID, content string) (*domain.Comment, error) {
+	content, err := uc.normalizeContent(content)
+	if err != nil {
+		return nil, err
 	}
 
 	// Ambil data existing untuk validasi kepemilikan
-	comment, err := uc.commentRepo.GetByID(ctx, id)
+	comment, err := uc.findComment(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -95,25 +405,25 @@ func (uc *CommentUC) Update(ctx context.Context, id uint, userID, content string
 		return nil, domain.ErrUnauthorizedAction
 	}
 
+	before := *comment
 	comment.Content = content
+	comment.ContentHTML = renderContent(content)
 	
 	if err := uc.commentRepo.Update(ctx, comment); err != nil {
 		return nil, err
 	}
 
 	// Invalidate Cache
-	if uc.redisRepo != nil {
-		cacheKey := fmt.Sprintf("comments:%s", comment.StoryUUID)
-		_ = uc.redisRepo.Del(ctx, cacheKey)
-	}
+	uc.invalidateStory(ctx, comment.StoryUUID)
+	uc.audit(ctx, domain.AuditCommentUpdate, domain.AuditTargetComment, comment.PublicID, comment.StoryUUID, before, comment)
 
 	return comment, nil
 }
 
 // Delete: Menghapus komentar (Hanya Pemilik)
-func (uc *CommentUC) Delete(ctx context.Context, id uint, userID string) error {
+func (uc *CommentUC) Delete(ctx context.Context, id string, userID string) error {
 	// Ambil data existing untuk tahu StoryUUID (buat hapus cache) & validasi user
-	comment, err := uc.commentRepo.GetByID(ctx, id)
+	comment, err := uc.findComment(ctx, id)
 	if err != nil {
 		return err
 	}
@@ -123,15 +433,192 @@ func (uc *CommentUC) Delete(ctx context.Context, id uint, userID string) error {
 		return domain.ErrUnauthorizedAction
 	}
 
-	if err := uc.commentRepo.Delete(ctx, id); err != nil {
+	if err := uc.commentRepo.Delete(ctx, comment.ID); err != nil {
 		return err
 	}
 
 	// Invalidate Cache
-	if uc.redisRepo != nil {
-		cacheKey := fmt.Sprintf("comments:%s", comment.StoryUUID)
-		_ = uc.redisRepo.Del(ctx, cacheKey)
+	uc.invalidateStory(ctx, comment.StoryUUID)
+	uc.audit(ctx, domain.AuditCommentDelete, domain.AuditTargetComment, comment.PublicID, comment.StoryUUID, comment, nil)
+
+	return nil
+}
+// Moderate: Mengubah status moderasi komentar (published / pending / hidden / shadow_banned)
+func (uc *CommentUC) Moderate(ctx context.Context, id string, status string) (*domain.Comment, error) {
+	if !domain.IsValidCommentStatus(status) {
+		return nil, domain.ErrInvalidStatus
+	}
+
+	comment, err := uc.findComment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *comment
+	comment.Status = status
+
+	if err := uc.commentRepo.Update(ctx, comment); err != nil {
+		return nil, err
+	}
+
+	// Invalidate Cache (komentar bisa muncul / hilang dari list story)
+	uc.invalidateStory(ctx, comment.StoryUUID)
+	uc.audit(ctx, domain.AuditCommentModerate, domain.AuditTargetComment, comment.PublicID, comment.StoryUUID, before, comment)
+
+	return comment, nil
+}
+
+// ArchiveStory: Arsipkan komentar story per batch sampai habis, lalu bersihkan cache & kirim
+// satu event agregat. Aman diulang (consumer retry / endpoint dipanggil dua kali)
+func (uc *CommentUC) ArchiveStory(ctx context.Context, storyUUID, reason string) (*domain.StoryArchiveResult, error) {
+	if storyUUID == "" {
+		return nil, domain.ErrInvalidStoryID
+	}
+
+	result := &domain.StoryArchiveResult{StoryUUID: storyUUID}
+	for {
+		n, err := uc.commentRepo.ArchiveByStory(ctx, storyUUID, reason, archiveBatchSize)
+		result.Archived += n
+		if err != nil {
+			// Batch yang sudah dipindah tetap terlihat hilang dari list
+			uc.invalidateStory(ctx, storyUUID)
+			return nil, err
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	if err := uc.commentRepo.ResetStoryStats(ctx, storyUUID); err != nil {
+		logger.Error("Failed to reset story comment stats", zap.String("story_id", storyUUID), zap.Error(err))
 	}
 
+	uc.invalidateStory(ctx, storyUUID)
+	if uc.redisRepo == nil {
+		return result, nil
+	}
+
+	// Story sudah tidak ada, counter generasi cukup hidup selama entry cache terlama masih bisa ada
+	_ = uc.redisRepo.Expire(ctx, generationCacheKey(storyUUID), listCacheTTL+uc.staleTTL)
+
+	if result.Archived > 0 && uc.eventsStream != "" {
+		err := uc.redisRepo.AppendStream(ctx, uc.eventsStream, map[string]interface{}{
+			"type":       "story.comments_archived",
+			"story_uuid": storyUUID,
+			"archived":   result.Archived,
+			"reason":     reason,
+		})
+		if err != nil {
+			logger.Error("Failed to emit comments archived event", zap.String("story_id", storyUUID), zap.Error(err))
+		}
+	}
+
+	uc.audit(ctx, domain.AuditStoryArchive, domain.AuditTargetStory, storyUUID, storyUUID, nil, result)
+
+	logger.Info("Story comments archived",
+		zap.String("story_id", storyUUID),
+		zap.Int64("archived", result.Archived),
+		zap.String("reason", reason),
+	)
+	return result, nil
+}
+
+// findComment: Resolve ID dari path API. Format utama ULID,
+// ID numerik lama masih diterima selama masa migrasi (ALLOW_LEGACY_IDS)
+func (uc *CommentUC) findComment(ctx context.Context, ref string) (*domain.Comment, error) {
+	comment, err := uc.lookupComment(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	ensureContentHTML(comment)
+	return comment, nil
+}
+
+func (uc *CommentUC) lookupComment(ctx context.Context, ref string) (*domain.Comment, error) {
+	if parsed, err := ulid.ParseStrict(ref); err == nil {
+		return uc.commentRepo.GetByPublicID(ctx, parsed.String())
+	}
+
+	if uc.allowLegacyIDs {
+		if id, err := strconv.ParseUint(ref, 10, 64); err == nil && id > 0 {
+			return uc.commentRepo.GetByID(ctx, uint(id))
+		}
+	}
+
+	return nil, domain.ErrInvalidCommentID
+}
+
+// invalidateStory: Naikkan generasi cache story (satu INCR), semua halaman list & statistik
+// generasi lama tidak dibaca lagi dan hilang sendiri oleh TTL. Dengan stale-while-revalidate,
+// generasi sebelumnya masih disajikan selama satu worker membangun ulang.
+// L1 instance lain di-evict lewat pub/sub
+func (uc *CommentUC) invalidateStory(ctx context.Context, storyUUID string) {
+	uc.evictLocal(storyUUID)
+	if uc.redisRepo == nil {
+		return
+	}
+
+	if err := uc.bumpGeneration(ctx, storyUUID); err != nil {
+		// Tanpa INCR, entry sebelum Redis down akan terbaca segar lagi setelah Redis pulih
+		uc.pendingMu.Lock()
+		uc.pendingInvalidations[storyUUID] = struct{}{}
+		uc.pendingMu.Unlock()
+		logger.Error("Failed to invalidate story cache, will retry when Redis recovers", zap.String("story_id", storyUUID), zap.Error(err))
+	}
+}
+
+// bumpGeneration: INCR generasi, hapus cache count & broadcast evict L1
+func (uc *CommentUC) bumpGeneration(ctx context.Context, storyUUID string) error {
+	if _, err := uc.redisRepo.Incr(ctx, generationCacheKey(storyUUID)); err != nil {
+		return err
+	}
+	_ = uc.redisRepo.Del(ctx, countCacheKey(storyUUID))
+
+	// Publish setelah generasi naik, supaya instance lain yang L1-nya miss tidak membaca data lama
+	_ = uc.redisRepo.Publish(ctx, cacheInvalidationChannel, storyUUID)
 	return nil
-}
\ No newline at end of file
+}
+
+// normalizeQuery: Limit & sort default
+func normalizeQuery(query domain.CommentQuery) domain.CommentQuery {
+	query.Limit = pagination.NormalizeLimit(query.Limit)
+	if query.Sort != domain.SortOldest {
+		query.Sort = domain.SortNewest
+	}
+	return query
+}
+
+// buildPage: Repo mengembalikan limit+1 baris, baris ekstra menandakan ada halaman berikutnya
+func buildPage(comments []domain.Comment, limit int) *domain.CommentPage {
+	ensureContentHTMLs(comments)
+	page := &domain.CommentPage{Items: comments}
+	if len(comments) > limit {
+		page.Items = comments[:limit]
+		last := page.Items[len(page.Items)-1]
+		page.NextCursor = pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.PublicID}.Encode()
+	}
+	return page
+}
+
+// listCacheKey: Key cache per story, generasi, sort & halaman, contoh: comments:<uuid>:g3:newest:20:first
+func listCacheKey(storyUUID string, gen int64, query domain.CommentQuery) string {
+	cursor := "first"
+	if query.After != nil {
+		cursor = query.After.Encode()
+	}
+	return fmt.Sprintf("comments:%s:g%d:%s:%d:%s", storyUUID, gen, query.Sort, query.Limit, cursor)
+}
+
+// generationCacheKey: Counter generasi cache story. Sengaja tanpa TTL: jika counter hilang dan
+// kembali ke 0, entry lama generasi kecil yang belum expired bisa terbaca lagi
+func generationCacheKey(storyUUID string) string {
+	return fmt.Sprintf("comments_gen:%s", storyUUID)
+}
+
+func statsCacheKey(storyUUID string, gen int64) string {
+	return fmt.Sprintf("comment_stats:%s:g%d", storyUUID, gen)
+}
+
+func countCacheKey(storyUUID string) string {
+	return fmt.Sprintf("comment_count:%s", storyUUID)
+}
@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+
+	"khalif-comment/internal/domain"
+	"khalif-comment/pkg/markdown"
+	"khalif-comment/pkg/textnorm"
+
+)
+
+const renderBatchSize = 500
+
+// renderContent: HTML yang disimpan di content_html. Satu-satunya tempat renderer dipanggil,
+// agar create, update dan backfill selalu menghasilkan output yang sama
+func renderContent(content string) string {
+	return markdown.Render(content)
+}
+
+// ensureContentHTML: Komentar lama yang belum dibackfill (migrasi 010 mengisi content_html = '')
+// dirender saat dibaca, agar client tidak pernah menerima HTML kosong untuk konten yang valid.
+// Backfill permanen tetap lewat RenderContent / `server content render`
+func ensureContentHTML(c *domain.Comment) {
+	if c.ContentHTML == "" && c.Content != "" {
+		c.ContentHTML = renderContent(c.Content)
+	}
+}
+
+func ensureContentHTMLs(comments []domain.Comment) {
+	for i := range comments {
+		ensureContentHTML(&comments[i])
+	}
+}
+
+// normalizeContent: Pipeline textnorm sesuai config lalu validasi panjang dalam grapheme cluster.
+// Dijalankan sebelum renderContent, jadi yang disimpan & dirender adalah teks hasil normalisasi
+func (uc *CommentUC) normalizeContent(content string) (string, error) {
+	content = uc.normalizer.Normalize(content)
+	if textnorm.IsBlank(content) {
+		return "", domain.ErrEmptyContent.WithFields(domain.FieldError{Field: "content", Code: "required"})
+	}
+
+	length := textnorm.Graphemes(content)
+	if length < uc.minLength {
+		return "", domain.ErrInvalidContent.WithFields(domain.FieldError{
+			Field: "content", Code: "min_length", Params: map[string]string{"param": strconv.Itoa(uc.minLength)},
+		})
+	}
+	if uc.maxLength > 0 && length > uc.maxLength {
+		return "", domain.ErrInvalidContent.WithFields(domain.FieldError{
+			Field: "content", Code: "max_length", Params: map[string]string{"param": strconv.Itoa(uc.maxLength)},
+		})
+	}
+	return content, nil
+}
+
+// RenderContent mengisi content_html komentar lama per batch (setelah migrasi, atau all = true
+// setelah aturan renderer berubah). Return jumlah komentar yang dirender.
+// Cache tidak di-invalidate di sini, jalankan FlushCache setelahnya
+func RenderContent(ctx context.Context, repo domain.CommentRepository, all bool) (int64, error) {
+	var total int64
+	var afterID uint
+	for {
+		comments, err := repo.ListForRender(ctx, afterID, renderBatchSize, all)
+		if err != nil {
+			return total, err
+		}
+		for _, c := range comments {
+			if err := repo.UpdateContentHTML(ctx, c.ID, renderContent(c.Content)); err != nil {
+				return total, err
+			}
+			total++
+		}
+		if len(comments) < renderBatchSize {
+			return total, nil
+		}
+		afterID = comments[len(comments)-1].ID
+	}
+}
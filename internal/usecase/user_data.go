@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+
+	"khalif-comment/internal/domain"
+	"khalif-comment/pkg/logger"
+
+)
+
+// Jumlah komentar per query saat export / erasure
+const userDataBatchSize = 500
+
+// ExportUser menulis arsip JSON data user ke w:
+//
+//	{"user_id": ..., "generated_at": ..., "comments": [...], "archived_comments": [...]}
+//
+// Service ini hanya menyimpan komentar (beserta status moderasinya), jadi arsip berisi komentar aktif
+// dan komentar yang diarsipkan karena story-nya dihapus. Ditulis per batch agar memori tetap kecil.
+// Jika error di tengah jalan, JSON yang sudah terkirim terpotong (tidak valid)
+func (uc *CommentUC) ExportUser(ctx context.Context, userID string, w io.Writer) error {
+	if userID == "" {
+		return domain.ErrInvalidUserID
+	}
+
+	bw := bufio.NewWriter(w)
+	header, err := json.Marshal(domain.UserExport{UserID: userID, GeneratedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	// Buka object header tanpa "}" penutup, lalu sambung dengan array komentar
+	bw.Write(header[:len(header)-1])
+
+	bw.WriteString(`,"comments":`)
+	err = writeJSONArray(bw, func(afterID uint) ([]domain.Comment, error) {
+		comments, err := uc.commentRepo.ListForExport(ctx, userID, afterID, userDataBatchSize)
+		ensureContentHTMLs(comments)
+		return comments, err
+	}, func(c domain.Comment) uint { return c.ID })
+	if err != nil {
+		return err
+	}
+
+	bw.WriteString(`,"archived_comments":`)
+	err = writeJSONArray(bw, func(afterID uint) ([]domain.ArchivedComment, error) {
+		comments, err := uc.commentRepo.ListArchivedForExport(ctx, userID, afterID, userDataBatchSize)
+		for i := range comments {
+			ensureContentHTML(&comments[i].Comment)
+		}
+		return comments, err
+	}, func(c domain.ArchivedComment) uint { return c.ID })
+	if err != nil {
+		return err
+	}
+
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// writeJSONArray: Menulis hasil fetch per batch (keyset berdasarkan id) sebagai satu array JSON
+func writeJSONArray[T any](w *bufio.Writer, fetch func(afterID uint) ([]T, error), idOf func(T) uint) error {
+	w.WriteByte('[')
+	var afterID uint
+	first := true
+	for {
+		items, err := fetch(afterID)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			data, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if !first {
+				w.WriteByte(',')
+			}
+			first = false
+			w.Write(data)
+			afterID = idOf(item)
+		}
+		if len(items) < userDataBatchSize {
+			break
+		}
+		// Kirim batch ke client, error tulis (client putus) dilaporkan di sini
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	w.WriteByte(']')
+	return nil
+}
+
+// EraseUser: Anonimkan (user_id diganti domain.ErasedUserID) atau hapus semua komentar user,
+// termasuk arsip, sesuai ERASURE_POLICY. Komentar tidak punya balasan bertingkat sehingga
+// list story tetap konsisten, jumlah komentar disesuaikan trigger stats, dan cache setiap story
+// yang terkena di-invalidate. Audit log tidak perlu disentuh karena snapshot komentar sudah diredaksi
+func (uc *CommentUC) EraseUser(ctx context.Context, userID string) (*domain.UserErasureResult, error) {
+	if userID == "" || userID == domain.ErasedUserID {
+		return nil, domain.ErrInvalidUserID
+	}
+
+	result := &domain.UserErasureResult{UserID: userID, Policy: uc.erasurePolicy}
+	stories := map[string]struct{}{}
+
+	// Invalidate juga saat gagal di tengah: batch yang sudah diproses harus langsung terlihat
+	defer func() {
+		for storyUUID := range stories {
+			uc.invalidateStory(ctx, storyUUID)
+		}
+	}()
+
+	for {
+		batch, err := uc.commentRepo.EraseByUser(ctx, userID, uc.erasurePolicy, userDataBatchSize)
+		for _, storyUUID := range batch {
+			stories[storyUUID] = struct{}{}
+		}
+		result.Comments += int64(len(batch))
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+	}
+	result.Stories = len(stories)
+
+	archived, err := uc.commentRepo.EraseArchivedByUser(ctx, userID, uc.erasurePolicy)
+	if err != nil {
+		return nil, err
+	}
+	result.ArchivedComments = archived
+
+	if uc.redisRepo != nil && uc.eventsStream != "" && result.Comments+result.ArchivedComments > 0 {
+		err := uc.redisRepo.AppendStream(ctx, uc.eventsStream, map[string]interface{}{
+			"type":     "user.comments_erased",
+			"user_id":  userID,
+			"policy":   uc.erasurePolicy,
+			"comments": result.Comments + result.ArchivedComments,
+		})
+		if err != nil {
+			logger.Error("Failed to emit comments erased event", zap.String("user_id", userID), zap.Error(err))
+		}
+	}
+
+	uc.audit(ctx, domain.AuditUserErase, domain.AuditTargetUser, userID, "", nil, result)
+
+	logger.Info("User comments erased",
+		zap.String("user_id", userID),
+		zap.String("policy", uc.erasurePolicy),
+		zap.Int64("comments", result.Comments),
+		zap.Int64("archived_comments", result.ArchivedComments),
+		zap.Int("stories", result.Stories),
+	)
+	return result, nil
+}
@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	"khalif-comment/pkg/logger"
+
+)
+
+const (
+	// Interval cek perubahan file JWKS
+	jwksCheckInterval = 30 * time.Second
+
+	// Kid yang tidak dikenal memicu cek ulang lebih cepat (key baru hasil rotasi), dibatasi agar tidak stat file tiap request
+	jwksUnknownKidInterval = 5 * time.Second
+)
+
+// keySet: Public key untuk RS256 / ES256. Key dari PEM tidak punya kid,
+// key dari JWKS dipilih berdasarkan header "kid" token
+type keySet struct {
+	static crypto.PublicKey // Dari PublicKeyFile, nil jika tidak dipakai
+
+	jwksFile string
+	mu       sync.RWMutex
+	byKID    map[string]crypto.PublicKey
+	modTime  time.Time
+	checked  time.Time
+}
+
+func newKeySet(publicKeyFile, jwksFile string) (*keySet, error) {
+	ks := &keySet{jwksFile: jwksFile, byKID: map[string]crypto.PublicKey{}}
+
+	if publicKeyFile != "" {
+		data, err := os.ReadFile(publicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("auth: read public key: %w", err)
+		}
+		if ks.static, err = parsePublicKeyPEM(data); err != nil {
+			return nil, fmt.Errorf("auth: %s: %w", publicKeyFile, err)
+		}
+	}
+
+	if jwksFile != "" {
+		if err := ks.reload(); err != nil {
+			return nil, err
+		}
+	}
+	return ks, nil
+}
+
+// lookup: Pilih key untuk token. Kid yang cocok di JWKS diutamakan, lalu key PEM,
+// lalu satu-satunya key JWKS jika token tidak menyertakan kid
+func (ks *keySet) lookup(kid string) (crypto.PublicKey, error) {
+	ks.refresh(kid)
+
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if kid != "" {
+		if key, ok := ks.byKID[kid]; ok {
+			return key, nil
+		}
+	}
+	if ks.static != nil {
+		return ks.static, nil
+	}
+	if kid == "" && len(ks.byKID) == 1 {
+		for _, key := range ks.byKID {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// refresh membaca ulang JWKS jika file berubah. Gagal baca = tetap memakai key lama
+func (ks *keySet) refresh(kid string) {
+	if ks.jwksFile == "" {
+		return
+	}
+
+	ks.mu.RLock()
+	_, known := ks.byKID[kid]
+	interval := jwksCheckInterval
+	if kid != "" && !known {
+		interval = jwksUnknownKidInterval
+	}
+	due := time.Since(ks.checked) >= interval
+	ks.mu.RUnlock()
+	if !due {
+		return
+	}
+
+	if err := ks.reload(); err != nil {
+		logger.Error("Failed to reload JWKS, keeping previous keys", zap.String("file", ks.jwksFile), zap.Error(err))
+	}
+}
+
+func (ks *keySet) reload() error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.checked = time.Now()
+
+	info, err := os.Stat(ks.jwksFile)
+	if err != nil {
+		return fmt.Errorf("auth: stat jwks: %w", err)
+	}
+	if info.ModTime().Equal(ks.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(ks.jwksFile)
+	if err != nil {
+		return fmt.Errorf("auth: read jwks: %w", err)
+	}
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("auth: %s: %w", ks.jwksFile, err)
+	}
+
+	ks.byKID = keys
+	ks.modTime = info.ModTime()
+	logger.Info("JWKS loaded", zap.String("file", ks.jwksFile), zap.Int("keys", len(keys)))
+	return nil
+}
+
+func parsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("not an RSA or EC public key in PEM format")
+}
+
+// jwk: Subset RFC 7517 yang dibutuhkan untuk key RSA & EC
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		// Key enkripsi bukan untuk verifikasi signature
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("rsa exponent too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"khalif-comment/pkg/health"
+
+)
+
+// ErrTokenRevoked: Token di-logout (jti) atau user di-ban / logout semua device setelah token terbit
+var ErrTokenRevoked = errors.New("token revoked")
+
+var revocationMetrics = expvar.NewMap("auth_revocation")
+
+// revokeUserScript: Timestamp "token terbit sebelum" per user hanya boleh maju,
+// revocation lama yang datang terlambat tidak boleh membatalkan yang lebih baru
+var revokeUserScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local before = tonumber(ARGV[1])
+if before > current then
+	redis.call('SET', KEYS[1], before, 'PX', ARGV[2])
+	return 1
+end
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 0
+`)
+
+// Revocations: Daftar token yang dicabut sebelum expired, disimpan di Redis agar berlaku di semua instance.
+//   - revoked:jti:<jti>   -> satu token, TTL sampai token expired (+ leeway)
+//   - revoked:user:<id>   -> unix timestamp, semua token user yang terbit sebelum / pada detik ini ditolak.
+//     TTL userTTL harus >= umur token terpanjang yang diterbitkan service auth
+type Revocations struct {
+	client     *redis.Client
+	monitor    *health.RedisMonitor
+	leeway     time.Duration
+	userTTL    time.Duration
+	failClosed bool
+}
+
+func NewRevocations(client *redis.Client, monitor *health.RedisMonitor, leeway, userTTL time.Duration, failClosed bool) *Revocations {
+	return &Revocations{client: client, monitor: monitor, leeway: leeway, userTTL: userTTL, failClosed: failClosed}
+}
+
+func revokedTokenKey(jti string) string   { return "revoked:jti:" + jti }
+func revokedUserKey(userID string) string { return "revoked:user:" + userID }
+
+// RevokeToken mencabut satu token. expiresAt kosong = dianggap hidup selama userTTL
+func (r *Revocations) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := r.userTTL
+	if !expiresAt.IsZero() {
+		ttl = time.Until(expiresAt) + r.leeway
+	}
+	if ttl <= 0 {
+		return nil // Token sudah expired, tidak perlu dicatat
+	}
+	return r.run(func() error {
+		return r.client.Set(ctx, revokedTokenKey(jti), 1, ttl).Err()
+	})
+}
+
+// RevokeUser mencabut semua token user yang terbit sebelum / pada waktu before (logout semua device, ban)
+func (r *Revocations) RevokeUser(ctx context.Context, userID string, before time.Time) error {
+	return r.run(func() error {
+		return revokeUserScript.Run(ctx, r.client, []string{revokedUserKey(userID)}, before.Unix(), r.userTTL.Milliseconds()).Err()
+	})
+}
+
+// Check mengembalikan ErrTokenRevoked jika token principal sudah dicabut.
+// Saat Redis tidak tersedia: fail-open (token diterima, dicatat di metrics) kecuali failClosed,
+// yang mengembalikan health.ErrRedisUnavailable
+func (r *Revocations) Check(ctx context.Context, p *Principal) error {
+	if r == nil {
+		return nil
+	}
+
+	keys := []string{revokedUserKey(p.UserID)}
+	if p.TokenID != "" {
+		keys = append(keys, revokedTokenKey(p.TokenID))
+	}
+
+	var values []any
+	err := r.run(func() (err error) {
+		values, err = r.client.MGet(ctx, keys...).Result()
+		return err
+	})
+	if err != nil {
+		revocationMetrics.Add("check_unavailable", 1)
+		if r.failClosed {
+			return health.ErrRedisUnavailable
+		}
+		return nil
+	}
+
+	if len(values) > 1 && values[1] != nil {
+		revocationMetrics.Add("rejected_token", 1)
+		return ErrTokenRevoked
+	}
+	if raw, ok := values[0].(string); ok {
+		before, err := strconv.ParseInt(raw, 10, 64)
+		// Token tanpa iat tidak bisa dibuktikan terbit setelah revocation
+		if err == nil && (p.IssuedAt.IsZero() || p.IssuedAt.Unix() <= before) {
+			revocationMetrics.Add("rejected_user", 1)
+			return ErrTokenRevoked
+		}
+	}
+	return nil
+}
+
+// run: Lewati Redis selama breaker terbuka dan laporkan hasil operasi ke monitor
+func (r *Revocations) run(op func() error) error {
+	if !r.monitor.Available() {
+		return health.ErrRedisUnavailable
+	}
+	err := op()
+	r.monitor.Report(err)
+	return err
+}
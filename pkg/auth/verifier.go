@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+	ErrNoSubject    = errors.New("token has no subject")
+)
+
+// Principal: Identitas pemanggil hasil verifikasi token
+type Principal struct {
+	UserID    string    // Dari claim "sub", fallback "user_id" (string maupun angka)
+	Role      string    // Dari claim "role", kosong = user biasa
+	TokenID   string    // Claim "jti", opsional
+	IssuedAt  time.Time // Claim "iat", zero jika tidak ada
+	ExpiresAt time.Time
+}
+
+// Options: Sumber key & aturan validasi token.
+// Minimal salah satu dari HMACSecret, PublicKeyFile atau JWKSFile harus diisi
+type Options struct {
+	HMACSecret    string        // HS256, shared secret lama
+	PublicKeyFile string        // RS256 / ES256, satu public key PEM (PKIX atau sertifikat)
+	JWKSFile      string        // RS256 / ES256, JWKS lokal, dibaca ulang saat file berubah (rotasi key)
+	Issuer        string        // Kosong = issuer tidak dicek
+	Audience      string        // Kosong = audience tidak dicek
+	Leeway        time.Duration // Toleransi selisih jam untuk exp / nbf / iat
+}
+
+// Verifier memvalidasi signature & claims token JWT
+type Verifier struct {
+	hmacSecret []byte
+	keys       *keySet
+	parser     *jwt.Parser
+}
+
+func NewVerifier(opts Options) (*Verifier, error) {
+	if opts.HMACSecret == "" && opts.PublicKeyFile == "" && opts.JWKSFile == "" {
+		return nil, errors.New("auth: no verification key configured")
+	}
+
+	keys, err := newKeySet(opts.PublicKeyFile, opts.JWKSFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Algoritma dibatasi sesuai key yang tersedia, token "none" / algoritma lain selalu ditolak
+	var methods []string
+	if opts.HMACSecret != "" {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if opts.PublicKeyFile != "" || opts.JWKSFile != "" {
+		methods = append(methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+	}
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(opts.Leeway),
+		jwt.WithJSONNumber(),
+	}
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(opts.Audience))
+	}
+
+	return &Verifier{
+		hmacSecret: []byte(opts.HMACSecret),
+		keys:       keys,
+		parser:     jwt.NewParser(parserOpts...),
+	}, nil
+}
+
+// Verify memvalidasi token dan mengembalikan principal-nya
+func (v *Verifier) Verify(tokenString string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	token, err := v.parser.ParseWithClaims(tokenString, claims, v.keyFunc)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	return principalFromClaims(claims)
+}
+
+func (v *Verifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(v.hmacSecret) == 0 {
+			return nil, errors.New("hmac tokens are not accepted")
+		}
+		return v.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	return v.keys.lookup(kid)
+}
+
+// principalFromClaims: "sub" adalah claim standar, "user_id" untuk token lama dari service auth
+func principalFromClaims(claims jwt.MapClaims) (*Principal, error) {
+	userID := claimString(claims["sub"])
+	if userID == "" {
+		userID = claimString(claims["user_id"])
+	}
+	if userID == "" {
+		return nil, ErrNoSubject
+	}
+
+	p := &Principal{
+		UserID:  userID,
+		Role:    claimString(claims["role"]),
+		TokenID: claimString(claims["jti"]),
+	}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		p.IssuedAt = iat.Time
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		p.ExpiresAt = exp.Time
+	}
+	return p, nil
+}
+
+// claimString: Claim string atau angka (json.Number) dijadikan string, tipe lain dianggap kosong
+func claimString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	return ""
+}
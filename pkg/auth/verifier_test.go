@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	"khalif-comment/pkg/logger"
+
+)
+
+const (
+	testIssuer   = "khalif-auth"
+	testAudience = "khalif-comment"
+	testSecret   = "rahasia-hmac"
+)
+
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
+// testKeys: Key RSA (PEM) & EC (JWKS, kid "ec-1") yang ditulis ke file sementara
+type testKeys struct {
+	rsa      *rsa.PrivateKey
+	ec       *ecdsa.PrivateKey
+	pemFile  string
+	pemBytes []byte
+	jwksFile string
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	k := &testKeys{
+		rsa:      rsaKey,
+		ec:       ecKey,
+		pemFile:  filepath.Join(dir, "public.pem"),
+		pemBytes: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}),
+		jwksFile: filepath.Join(dir, "jwks.json"),
+	}
+	if err := os.WriteFile(k.pemFile, k.pemBytes, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "EC", "kid": "ec-1", "use": "sig", "crv": "P-256",
+		"x": b64(ecKey.X.FillBytes(make([]byte, 32))),
+		"y": b64(ecKey.Y.FillBytes(make([]byte, 32))),
+	}}})
+	if err := os.WriteFile(k.jwksFile, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, claims jwt.MapClaims, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestVerify(t *testing.T) {
+	keys := newTestKeys(t)
+	now := time.Now()
+
+	claims := func(overrides jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub": "user-1",
+			"iss": testIssuer,
+			"aud": testAudience,
+			"iat": now.Unix(),
+			"exp": now.Add(time.Hour).Unix(),
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+			} else {
+				c[k] = v
+			}
+		}
+		return c
+	}
+
+	rsOnly := Options{PublicKeyFile: keys.pemFile, Issuer: testIssuer, Audience: testAudience}
+	all := Options{HMACSecret: testSecret, PublicKeyFile: keys.pemFile, JWKSFile: keys.jwksFile, Issuer: testIssuer, Audience: testAudience}
+	noneToken := sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, claims(nil), "")
+
+	cases := []struct {
+		name    string
+		opts    Options
+		token   string
+		wantErr error
+		wantSub string
+	}{
+		{"rs256 valid", rsOnly, sign(t, jwt.SigningMethodRS256, keys.rsa, claims(nil), ""), nil, "user-1"},
+		{"es256 jwks by kid", all, sign(t, jwt.SigningMethodES256, keys.ec, claims(nil), "ec-1"), nil, "user-1"},
+		{"hs256 valid", all, sign(t, jwt.SigningMethodHS256, []byte(testSecret), claims(nil), ""), nil, "user-1"},
+		{"numeric user_id fallback", rsOnly, sign(t, jwt.SigningMethodRS256, keys.rsa, claims(jwt.MapClaims{"sub": nil, "user_id": 42}), ""), nil, "42"},
+		{"alg none", all, noneToken, ErrInvalidToken, ""},
+		{"hs256 with rs public key as secret", rsOnly, sign(t, jwt.SigningMethodHS256, keys.pemBytes, claims(nil), ""), ErrInvalidToken, ""},
+		{"hs256 wrong secret", all, sign(t, jwt.SigningMethodHS256, []byte("salah"), claims(nil), ""), ErrInvalidToken, ""},
+		{"rs384 not allowed", rsOnly, sign(t, jwt.SigningMethodRS384, keys.rsa, claims(nil), ""), ErrInvalidToken, ""},
+		{"signed by other key", rsOnly, sign(t, jwt.SigningMethodES256, keys.ec, claims(nil), ""), ErrInvalidToken, ""},
+		{"unknown kid", all, sign(t, jwt.SigningMethodES256, keys.ec, claims(nil), "ec-2"), ErrInvalidToken, ""},
+		{"wrong issuer", rsOnly, sign(t, jwt.SigningMethodRS256, keys.rsa, claims(jwt.MapClaims{"iss": "evil"}), ""), ErrInvalidToken, ""},
+		{"missing issuer", rsOnly, sign(t, jwt.SigningMethodRS256, keys.rsa, claims(jwt.MapClaims{"iss": nil}), ""), ErrInvalidToken, ""},
+		{"wrong audience", rsOnly, sign(t, jwt.SigningMethodRS256, keys.rsa, claims(jwt.MapClaims{"aud": "khalif-stories"}), ""), ErrInvalidToken, ""},
+		{"audience list", rsOnly, sign(t, jwt.SigningMethodRS256, keys.rsa, claims(jwt.MapClaims{"aud": []string{"x", testAudience}}), ""), nil, "user-1"},
+		{"expired", rsOnly, sign(t, jwt.SigningMethodRS256, keys.rsa, claims(jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()}), ""), ErrTokenExpired, ""},
+		{"missing exp", rsOnly, sign(t, jwt.SigningMethodRS256, keys.rsa, claims(jwt.MapClaims{"exp": nil}), ""), ErrInvalidToken, ""},
+		{"not yet valid", rsOnly, sign(t, jwt.SigningMethodRS256, keys.rsa, claims(jwt.MapClaims{"nbf": now.Add(time.Hour).Unix()}), ""), ErrInvalidToken, ""},
+		{"issued in future", rsOnly, sign(t, jwt.SigningMethodRS256, keys.rsa, claims(jwt.MapClaims{"iat": now.Add(time.Hour).Unix()}), ""), ErrInvalidToken, ""},
+		{"no subject", rsOnly, sign(t, jwt.SigningMethodRS256, keys.rsa, claims(jwt.MapClaims{"sub": nil}), ""), ErrNoSubject, ""},
+		{"garbage", rsOnly, "bukan.token.jwt", ErrInvalidToken, ""},
+		{"empty", rsOnly, "", ErrInvalidToken, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := NewVerifier(tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := v.Verify(tc.token)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("Verify err = %v, want %v", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if p.UserID != tc.wantSub {
+				t.Errorf("UserID = %q, want %q", p.UserID, tc.wantSub)
+			}
+		})
+	}
+}
+
+func TestNewVerifierRequiresKey(t *testing.T) {
+	if _, err := NewVerifier(Options{Issuer: testIssuer}); err == nil {
+		t.Fatal("expected error without any verification key")
+	}
+}
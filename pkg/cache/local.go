@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"strings"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+
+)
+
+// Local: Cache in-process (L1) di depan Redis. Menyimpan value yang sudah di-decode
+// sehingga cache hit tidak butuh round trip Redis maupun json.Unmarshal.
+// Dibatasi jumlah entry (LRU) dan TTL agar data antar instance tidak menyimpang terlalu lama
+type Local struct {
+	lru *expirable.LRU[string, any]
+}
+
+// NewLocal membuat L1 cache. size <= 0 berarti L1 dimatikan (return nil, aman dipanggil methodnya)
+func NewLocal(size int, ttl time.Duration) *Local {
+	if size <= 0 {
+		return nil
+	}
+	return &Local{lru: expirable.NewLRU[string, any](size, nil, ttl)}
+}
+
+// Get mengambil value dan mencatat hit / miss tier L1
+func (l *Local) Get(key string) (any, bool) {
+	if l == nil {
+		return nil, false
+	}
+	value, ok := l.lru.Get(key)
+	if ok {
+		Hit(TierLocal)
+	} else {
+		Miss(TierLocal)
+	}
+	return value, ok
+}
+
+func (l *Local) Set(key string, value any) {
+	if l == nil {
+		return
+	}
+	l.lru.Add(key, value)
+}
+
+func (l *Local) Remove(key string) {
+	if l == nil {
+		return
+	}
+	l.lru.Remove(key)
+}
+
+// RemovePrefix menghapus semua key dengan prefix tertentu (linear, jumlah entry dibatasi size)
+func (l *Local) RemovePrefix(prefix string) {
+	if l == nil {
+		return
+	}
+	for _, key := range l.lru.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			l.lru.Remove(key)
+		}
+	}
+}
+
+// Purge mengosongkan seluruh L1, dipakai saat pesan invalidasi mungkin terlewat
+func (l *Local) Purge() {
+	if l == nil {
+		return
+	}
+	l.lru.Purge()
+}
@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"expvar"
+
+)
+
+// Tier cache untuk counter hit / miss
+const (
+	TierLocal = "local" // L1 in-process
+	TierRedis = "redis" // L2 shared
+)
+
+// stats dipublikasikan lewat expvar di /internal/debug/vars, contoh: {"cache": {"local_hits": 10, ...}}
+var stats = expvar.NewMap("cache")
+
+func Hit(tier string) {
+	stats.Add(tier+"_hits", 1)
+}
+
+func Miss(tier string) {
+	stats.Add(tier+"_misses", 1)
+}
+
+// Count menambah counter bebas, misal "redis_stale" atau "invalidations_received"
+func Count(name string) {
+	stats.Add(name, 1)
+}
@@ -80,7 +80,9 @@ func getRootDSNAndDBName(dsn string) (string, string) {
 	return rootDSN, dbName
 }
 
-func ResetSchema(db *gorm.DB) {
+// ResetSchema menghapus seluruh isi schema public. Jangan dipanggil langsung,
+// gunakan command "reset" yang punya pengaman environment & konfirmasi
+func ResetSchema(db *gorm.DB) error {
 	queries := []string{
 		"DROP SCHEMA public CASCADE;",
 		"CREATE SCHEMA public;",
@@ -89,7 +91,8 @@ func ResetSchema(db *gorm.DB) {
 
 	for _, q := range queries {
 		if err := db.Exec(q).Error; err != nil {
-			log.Fatal(err)
+			return err
 		}
 	}
-}
\ No newline at end of file
+	return nil
+}
@@ -1,8 +1,19 @@
 package database
 
 import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
 	"embed"
-	"strings"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -14,35 +25,325 @@ import (
 //go:embed schema/*.sql
 var schemaFS embed.FS
 
+// Key pg_advisory_lock agar hanya satu pod yang menjalankan migrasi dalam satu waktu
+const migrationLockKey int64 = 0x6b68616c6966 // "khalif"
+
+// Format nama file: 001_init_schema.up.sql / 001_init_schema.down.sql
+var migrationFileRe = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+var ErrChecksumMismatch = errors.New("applied migration was modified")
+
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string // Kosong = migrasi tidak bisa di-rollback
+	Checksum string // sha256 dari file up
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+	Modified  bool // File up berubah setelah migrasi dijalankan
+}
+
+// Migrator menjalankan migrasi SQL berversi dari schemaFS dan mencatatnya di tabel schema_migrations
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := loadMigrations(schemaFS, "schema")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: sqlDB, migrations: migrations}, nil
+}
+
+// RunMigrations dipanggil saat startup: jalankan semua migrasi yang belum diterapkan
 func RunMigrations(db *gorm.DB) {
-	// Pastikan file 'schema/001_init_schema.sql' ada di folder pkg/database/schema/
-	content, err := schemaFS.ReadFile("schema/001_init_schema.sql")
+	m, err := NewMigrator(db)
+	if err != nil {
+		logger.Fatal("Failed to load migrations", zap.Error(err))
+	}
+
+	applied, err := m.Up(context.Background())
 	if err != nil {
-		logger.Fatal("Failed to read migration file from embed", zap.Error(err))
+		logger.Fatal("Failed to run migrations", zap.Error(err))
 	}
 
-	blocks := strings.Split(string(content), "--SEPARATOR--")
+	logger.Info("Database migration executed successfully", zap.Int("applied", applied))
+}
+
+// Up menjalankan semua migrasi yang belum diterapkan secara berurutan, masing-masing dalam transaksi sendiri
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if checksum, ok := done[mig.Version]; ok {
+				if checksum != mig.Checksum {
+					return fmt.Errorf("%w: %03d_%s", ErrChecksumMismatch, mig.Version, mig.Name)
+				}
+				continue
+			}
+
+			if err := m.apply(ctx, conn, mig); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down me-rollback sejumlah steps migrasi terakhir
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := done[mig.Version]; !ok {
+				continue
+			}
 
-	for _, block := range blocks {
-		trimmedBlock := strings.TrimSpace(block)
-		if trimmedBlock == "" {
-			continue
+			if err := m.revert(ctx, conn, mig); err != nil {
+				return err
+			}
+			reverted++
 		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Redo me-rollback migrasi terakhir lalu menjalankannya lagi
+func (m *Migrator) Redo(ctx context.Context) error {
+	if _, err := m.Down(ctx, 1); err != nil {
+		return err
+	}
+	_, err := m.Up(ctx)
+	return err
+}
+
+// Status mengembalikan semua migrasi beserta waktu diterapkannya
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.ensureTable(ctx, m.db); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT version, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-		if err := db.Exec(trimmedBlock).Error; err != nil {
-			logger.Fatal("Failed to execute migration block", 
-				zap.String("query_snippet", trimmedBlock[:min(len(trimmedBlock), 50)]), 
-				zap.Error(err),
-			)
+	type appliedRow struct {
+		checksum  string
+		appliedAt time.Time
+	}
+	applied := map[int64]appliedRow{}
+	for rows.Next() {
+		var version int64
+		var row appliedRow
+		if err := rows.Scan(&version, &row.checksum, &row.appliedAt); err != nil {
+			return nil, err
 		}
+		applied[version] = row
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	logger.Info("Database migration executed successfully")
+	result := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		status := MigrationStatus{Migration: mig}
+		if row, ok := applied[mig.Version]; ok {
+			appliedAt := row.appliedAt
+			status.AppliedAt = &appliedAt
+			status.Modified = row.checksum != mig.Checksum
+		}
+		result = append(result, status)
+	}
+	return result, nil
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
+// withLock memakai satu koneksi khusus karena advisory lock terikat ke session Postgres
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
 	}
-	return b
-}
\ No newline at end of file
+	return fn(conn)
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, db execer) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version      BIGINT PRIMARY KEY,
+		name         TEXT NOT NULL,
+		checksum     TEXT NOT NULL,
+		applied_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+		execution_ms BIGINT NOT NULL DEFAULT 0
+	)`)
+	return err
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]string, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, checksum FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := map[int64]string{}
+	for rows.Next() {
+		var version int64
+		var checksum string
+		if err := rows.Scan(&version, &checksum); err != nil {
+			return nil, err
+		}
+		done[version] = checksum
+	}
+	return done, rows.Err()
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, mig Migration) error {
+	start := time.Now()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Tanpa argumen pgx memakai simple protocol, jadi satu file berisi banyak statement bisa langsung dieksekusi
+	if _, err := tx.ExecContext(ctx, mig.Up); err != nil {
+		return fmt.Errorf("migration %03d_%s up: %w", mig.Version, mig.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, name, checksum, execution_ms) VALUES ($1, $2, $3, $4)`,
+		mig.Version, mig.Name, mig.Checksum, time.Since(start).Milliseconds(),
+	); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	logger.Info("Migration applied",
+		zap.Int64("version", mig.Version),
+		zap.String("name", mig.Name),
+		zap.Duration("took", time.Since(start)),
+	)
+	return nil
+}
+
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, mig Migration) error {
+	if mig.Down == "" {
+		return fmt.Errorf("migration %03d_%s has no down file", mig.Version, mig.Name)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, mig.Down); err != nil {
+		return fmt.Errorf("migration %03d_%s down: %w", mig.Version, mig.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	logger.Info("Migration reverted", zap.Int64("version", mig.Version), zap.String("name", mig.Name))
+	return nil
+}
+
+// loadMigrations membaca & memasangkan file up/down, diurutkan berdasarkan versi
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d used by %s and %s", version, mig.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			sum := sha256.Sum256(content)
+			mig.Up = string(content)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %03d_%s has no up file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
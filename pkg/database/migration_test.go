@@ -0,0 +1,117 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+)
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+	sum := func(s string) string {
+		h := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(h[:])
+	}
+
+	cases := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []Migration
+		wantErr string
+	}{
+		{
+			name: "sorted by version not name",
+			files: fstest.MapFS{
+				"schema/010_b.up.sql":   file("B"),
+				"schema/010_b.down.sql": file("-B"),
+				"schema/002_z.up.sql":   file("Z"),
+				"schema/002_z.down.sql": file("-Z"),
+				"schema/000_a.up.sql":   file("A"),
+			},
+			want: []Migration{
+				{Version: 0, Name: "a", Up: "A", Checksum: sum("A")},
+				{Version: 2, Name: "z", Up: "Z", Down: "-Z", Checksum: sum("Z")},
+				{Version: 10, Name: "b", Up: "B", Down: "-B", Checksum: sum("B")},
+			},
+		},
+		{
+			name: "checksum covers up file only",
+			files: fstest.MapFS{
+				"schema/001_x.up.sql":   file("CREATE TABLE x ();\n"),
+				"schema/001_x.down.sql": file("DROP TABLE x;\n"),
+			},
+			want: []Migration{
+				{Version: 1, Name: "x", Up: "CREATE TABLE x ();\n", Down: "DROP TABLE x;\n", Checksum: sum("CREATE TABLE x ();\n")},
+			},
+		},
+		{
+			name:  "missing down file is allowed",
+			files: fstest.MapFS{"schema/001_x.up.sql": file("X")},
+			want:  []Migration{{Version: 1, Name: "x", Up: "X", Checksum: sum("X")}},
+		},
+		{
+			name:    "missing up file",
+			files:   fstest.MapFS{"schema/001_x.down.sql": file("X")},
+			wantErr: "has no up file",
+		},
+		{
+			name: "version conflict",
+			files: fstest.MapFS{
+				"schema/001_x.up.sql": file("X"),
+				"schema/001_y.up.sql": file("Y"),
+			},
+			wantErr: "used by",
+		},
+		{
+			name:    "invalid name",
+			files:   fstest.MapFS{"schema/001_Init.up.sql": file("X")},
+			wantErr: "invalid migration file name",
+		},
+		{
+			name:    "unknown direction",
+			files:   fstest.MapFS{"schema/001_x.sql": file("X")},
+			wantErr: "invalid migration file name",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := loadMigrations(tc.files, "schema")
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("err = %v, want containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %d migrations, want %d", len(got), len(tc.want))
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("migration %d = %+v, want %+v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+// Migrasi yang di-embed harus selalu bisa dimuat, berurutan tanpa versi ganda & bisa di-rollback
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(schemaFS, "schema")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, mig := range migrations {
+		if i > 0 && mig.Version <= migrations[i-1].Version {
+			t.Errorf("migration %03d_%s out of order", mig.Version, mig.Name)
+		}
+		if strings.TrimSpace(mig.Down) == "" {
+			t.Errorf("migration %03d_%s has no down file", mig.Version, mig.Name)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+
+	"khalif-comment/pkg/logger"
+
+)
+
+// Replica memilih koneksi untuk query read-only.
+// Query diarahkan ke read replica selama replica sehat dan lag-nya di bawah batas,
+// selain itu (atau jika DATABASE_READ_URL kosong) fallback ke primary
+type Replica struct {
+	primary *gorm.DB
+	replica *gorm.DB
+	maxLag  time.Duration
+	healthy atomic.Bool
+}
+
+func NewReplica(primary, replica *gorm.DB, maxLag time.Duration) *Replica {
+	return &Replica{primary: primary, replica: replica, maxLag: maxLag}
+}
+
+// Reader mengembalikan koneksi untuk query yang boleh sedikit tertinggal (list, count, search)
+func (r *Replica) Reader() *gorm.DB {
+	if r.replica != nil && r.healthy.Load() {
+		return r.replica
+	}
+	return r.primary
+}
+
+// Status untuk endpoint readiness: "disabled", "healthy" atau "fallback" (read diarahkan ke primary)
+func (r *Replica) Status() string {
+	switch {
+	case r.replica == nil:
+		return "disabled"
+	case r.healthy.Load():
+		return "healthy"
+	default:
+		return "fallback"
+	}
+}
+
+// Monitor mengecek kesehatan & lag replica secara berkala sampai ctx dibatalkan
+func (r *Replica) Monitor(ctx context.Context, interval time.Duration) {
+	if r.replica == nil {
+		return
+	}
+
+	r.check(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.check(ctx)
+		}
+	}
+}
+
+func (r *Replica) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	// Lag 0 jika semua WAL yang diterima sudah di-replay (replica idle tidak dianggap tertinggal).
+	// Di server non-replica fungsi pg_last_* bernilai NULL sehingga lag juga 0
+	var lagSeconds float64
+	err := r.replica.WithContext(ctx).Raw(`
+		SELECT CASE
+			WHEN pg_last_wal_receive_lsn() IS NULL OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+			ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+		END`).Scan(&lagSeconds).Error
+
+	lag := time.Duration(lagSeconds * float64(time.Second))
+	healthy := err == nil && lag <= r.maxLag
+
+	// Log hanya saat status berubah agar tidak membanjiri log
+	if previous := r.healthy.Swap(healthy); previous != healthy {
+		if healthy {
+			logger.Info("Read replica healthy, routing reads to replica", zap.Duration("lag", lag))
+		} else {
+			logger.Error("Read replica unavailable, falling back to primary", zap.Duration("lag", lag), zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,14 @@
+-- Tabel utama komentar. Dulu dibuat GORM AutoMigrate sebelum 001 dijalankan, sekarang jadi migrasi
+-- tersendiri agar 001 (sudah terpasang di production) tetap sama persis byte-per-byte dan checksum-nya cocok.
+-- IF NOT EXISTS: di database lama tabel ini sudah ada, migrasi ini hanya dicatat
+CREATE TABLE IF NOT EXISTS comments (
+    id         BIGSERIAL PRIMARY KEY,
+    story_uuid TEXT NOT NULL,
+    user_id    TEXT NOT NULL,
+    content    TEXT NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_story_uuid ON comments (story_uuid);
+CREATE INDEX IF NOT EXISTS idx_comments_user_id ON comments (user_id);
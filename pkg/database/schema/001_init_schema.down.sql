@@ -0,0 +1,5 @@
+-- Role readonly_user berlaku di level cluster (dipakai DB lain juga), jadi tidak ikut di-drop.
+-- Tabel comments milik 000_comments_table
+DROP TRIGGER IF EXISTS update_comments_modtime ON comments;
+
+DROP FUNCTION IF EXISTS update_updated_at_column();
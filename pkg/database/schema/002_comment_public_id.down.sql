@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_comments_public_id;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS public_id;
+
+DROP FUNCTION IF EXISTS generate_ulid(TIMESTAMPTZ);
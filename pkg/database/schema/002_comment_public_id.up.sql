@@ -0,0 +1,26 @@
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS public_id VARCHAR(26);
+
+-- ULID generator untuk backfill public_id komentar lama.
+-- Timestamp diambil dari created_at agar urutan ULID tetap sesuai waktu komentar dibuat
+CREATE OR REPLACE FUNCTION generate_ulid(ts TIMESTAMPTZ DEFAULT clock_timestamp())
+RETURNS TEXT AS $$
+DECLARE
+    alphabet TEXT := '0123456789ABCDEFGHJKMNPQRSTVWXYZ';
+    millis   BIGINT := (EXTRACT(EPOCH FROM ts) * 1000)::BIGINT;
+    bits     BIT(130);
+    output   TEXT := '';
+BEGIN
+    -- 2 bit padding + 48 bit timestamp + 80 bit random = 26 karakter Crockford base32
+    bits := B'00' || millis::BIT(48) || ('x' || substr(md5(random()::TEXT || clock_timestamp()::TEXT), 1, 20))::BIT(80);
+    FOR i IN 0..25 LOOP
+        output := output || substr(alphabet, substring(bits FROM i * 5 + 1 FOR 5)::INT + 1, 1);
+    END LOOP;
+    RETURN output;
+END;
+$$ LANGUAGE plpgsql VOLATILE;
+
+UPDATE comments SET public_id = generate_ulid(COALESCE(created_at, NOW())) WHERE public_id IS NULL;
+
+ALTER TABLE comments ALTER COLUMN public_id SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_comments_public_id ON comments (public_id);
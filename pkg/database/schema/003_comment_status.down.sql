@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_comments_status;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS status;
@@ -0,0 +1,4 @@
+-- Status moderasi: published / pending / hidden / shadow_banned
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'published';
+
+CREATE INDEX IF NOT EXISTS idx_comments_status ON comments (status);
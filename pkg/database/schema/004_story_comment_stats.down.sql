@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS comments_stats_update ON comments;
+
+DROP TRIGGER IF EXISTS comments_stats_insert_delete ON comments;
+
+DROP FUNCTION IF EXISTS sync_story_comment_stats();
+
+DROP FUNCTION IF EXISTS bump_story_comment_stats(TEXT, BIGINT);
+
+DROP TABLE IF EXISTS story_comment_stats;
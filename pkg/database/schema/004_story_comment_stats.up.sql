@@ -0,0 +1,51 @@
+-- Jumlah komentar published per story (denormalisasi untuk feed khalif-stories)
+CREATE TABLE IF NOT EXISTS story_comment_stats (
+    story_uuid    TEXT PRIMARY KEY,
+    comment_count BIGINT NOT NULL DEFAULT 0,
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE OR REPLACE FUNCTION bump_story_comment_stats(p_story TEXT, p_delta BIGINT)
+RETURNS VOID AS $$
+BEGIN
+    INSERT INTO story_comment_stats (story_uuid, comment_count, updated_at)
+    VALUES (p_story, GREATEST(p_delta, 0), NOW())
+    ON CONFLICT (story_uuid) DO UPDATE
+        SET comment_count = GREATEST(story_comment_stats.comment_count + p_delta, 0),
+            updated_at    = NOW();
+END;
+$$ LANGUAGE plpgsql;
+
+-- Dijalankan di transaksi yang sama dengan INSERT / UPDATE / DELETE komentar
+CREATE OR REPLACE FUNCTION sync_story_comment_stats()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        IF OLD.status = 'published' THEN
+            PERFORM bump_story_comment_stats(OLD.story_uuid, -1);
+        END IF;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        IF NEW.status = 'published' THEN
+            PERFORM bump_story_comment_stats(NEW.story_uuid, 1);
+        END IF;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS comments_stats_insert_delete ON comments;
+
+CREATE TRIGGER comments_stats_insert_delete AFTER INSERT OR DELETE ON comments FOR EACH ROW EXECUTE PROCEDURE sync_story_comment_stats();
+
+DROP TRIGGER IF EXISTS comments_stats_update ON comments;
+
+-- Edit konten tidak mengubah jumlah, jadi trigger hanya jalan saat status / story berubah
+CREATE TRIGGER comments_stats_update AFTER UPDATE ON comments FOR EACH ROW
+    WHEN (OLD.status IS DISTINCT FROM NEW.status OR OLD.story_uuid IS DISTINCT FROM NEW.story_uuid)
+    EXECUTE PROCEDURE sync_story_comment_stats();
+
+-- Backfill awal untuk story yang belum punya baris stats
+INSERT INTO story_comment_stats (story_uuid, comment_count, updated_at)
+SELECT story_uuid, COUNT(*), NOW() FROM comments WHERE status = 'published' GROUP BY story_uuid
+ON CONFLICT (story_uuid) DO NOTHING;
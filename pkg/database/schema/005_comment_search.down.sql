@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_comments_search_vector;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS search_vector;
+
+DROP TEXT SEARCH CONFIGURATION IF EXISTS khalif_comment;
@@ -0,0 +1,18 @@
+-- Konfigurasi full-text search komentar. Pakai stemmer Indonesian jika tersedia (PostgreSQL 12+),
+-- fallback ke 'simple' (tanpa stemming) supaya migrasi tetap jalan di versi lama
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'khalif_comment') THEN
+        IF EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'indonesian') THEN
+            CREATE TEXT SEARCH CONFIGURATION khalif_comment (COPY = indonesian);
+        ELSE
+            CREATE TEXT SEARCH CONFIGURATION khalif_comment (COPY = simple);
+        END IF;
+    END IF;
+END
+$$;
+
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (to_tsvector('khalif_comment', coalesce(content, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_comments_search_vector ON comments USING GIN (search_vector);
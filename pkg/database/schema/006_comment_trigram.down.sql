@@ -0,0 +1,2 @@
+-- Extension pg_trgm dibiarkan karena bisa dipakai objek lain di database yang sama
+DROP INDEX IF EXISTS idx_comments_content_trgm;
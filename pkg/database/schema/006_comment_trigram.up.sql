@@ -0,0 +1,4 @@
+-- Trigram index untuk fuzzy search (slang / typo) & deteksi spam yang mirip antar story
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_comments_content_trgm ON comments USING GIN (content gin_trgm_ops);
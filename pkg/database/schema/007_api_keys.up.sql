@@ -0,0 +1,14 @@
+-- API key service-to-service (khalif-stories, service auth, dst) untuk endpoint /internal.
+-- Key plaintext hanya ditampilkan sekali saat dibuat, yang disimpan hanya sha256-nya
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           BIGSERIAL PRIMARY KEY,
+    prefix       VARCHAR(16) NOT NULL UNIQUE, -- Bagian publik key, untuk lookup & ditampilkan di log
+    key_hash     CHAR(64) NOT NULL,
+    service      VARCHAR(100) NOT NULL,
+    scopes       TEXT[] NOT NULL DEFAULT '{}',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_service ON api_keys (service);
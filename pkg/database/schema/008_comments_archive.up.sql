@@ -0,0 +1,17 @@
+-- Komentar yang diarsipkan (story dihapus di khalif-stories, dst). Dipindah dari tabel comments
+-- supaya tidak ikut di index / query list, tapi masih bisa dipulihkan atau diaudit
+CREATE TABLE IF NOT EXISTS comments_archive (
+    id             BIGINT PRIMARY KEY,
+    public_id      VARCHAR(26) NOT NULL,
+    story_uuid     TEXT NOT NULL,
+    user_id        TEXT NOT NULL,
+    content        TEXT NOT NULL,
+    status         VARCHAR(20) NOT NULL,
+    created_at     TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ,
+    archived_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    archive_reason VARCHAR(50) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_archive_story_uuid ON comments_archive (story_uuid);
+CREATE INDEX IF NOT EXISTS idx_comments_archive_user_id ON comments_archive (user_id);
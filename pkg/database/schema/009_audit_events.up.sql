@@ -0,0 +1,40 @@
+-- Audit log semua aksi yang mengubah komentar (create, update, delete, moderasi, arsip, erasure).
+-- Append-only: UPDATE selalu ditolak, DELETE hanya boleh dari job retensi (SET LOCAL khalif.audit_retention = 'on')
+CREATE TABLE IF NOT EXISTS audit_events (
+    id          BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor_type  VARCHAR(20) NOT NULL,  -- user / service / system
+    actor_id    TEXT NOT NULL DEFAULT '',
+    actor_role  VARCHAR(50) NOT NULL DEFAULT '',
+    action      VARCHAR(50) NOT NULL,  -- comment.create, comment.moderate, story.archive, dst
+    target_type VARCHAR(20) NOT NULL,  -- comment / story / user
+    target_id   TEXT NOT NULL,
+    story_uuid  TEXT NOT NULL DEFAULT '',
+    before      JSONB,
+    after       JSONB,
+    ip          TEXT NOT NULL DEFAULT '',
+    request_id  TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_occurred_at ON audit_events (occurred_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events (target_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events (actor_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_story ON audit_events (story_uuid, id) WHERE story_uuid <> '';
+
+CREATE OR REPLACE FUNCTION audit_events_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('khalif.audit_retention', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'audit_events is append-only (% rejected)', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_events_no_update ON audit_events;
+
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE OR DELETE ON audit_events FOR EACH ROW EXECUTE PROCEDURE audit_events_append_only();
+
+DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events;
+
+CREATE TRIGGER audit_events_no_truncate BEFORE TRUNCATE ON audit_events FOR EACH STATEMENT EXECUTE PROCEDURE audit_events_append_only();
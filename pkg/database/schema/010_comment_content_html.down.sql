@@ -0,0 +1,3 @@
+ALTER TABLE comments_archive DROP COLUMN IF EXISTS content_html;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS content_html;
@@ -0,0 +1,5 @@
+-- HTML hasil render Markdown (pkg/markdown), dirender sekali saat create / update, bukan setiap read.
+-- Komentar lama berisi '' sampai diisi dengan `server content render`
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS content_html TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE comments_archive ADD COLUMN IF NOT EXISTS content_html TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,33 @@
+DROP TRIGGER IF EXISTS comments_stats_update ON comments;
+
+CREATE TRIGGER comments_stats_update AFTER UPDATE ON comments FOR EACH ROW
+    WHEN (OLD.status IS DISTINCT FROM NEW.status OR OLD.story_uuid IS DISTINCT FROM NEW.story_uuid)
+    EXECUTE PROCEDURE sync_story_comment_stats();
+
+CREATE OR REPLACE FUNCTION sync_story_comment_stats()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        IF OLD.status = 'published' THEN
+            PERFORM bump_story_comment_stats(OLD.story_uuid, -1);
+        END IF;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        IF NEW.status = 'published' THEN
+            PERFORM bump_story_comment_stats(NEW.story_uuid, 1);
+        END IF;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION bump_story_comment_stats(p_story TEXT, p_delta BIGINT)
+RETURNS VOID AS $$
+BEGIN
+    INSERT INTO story_comment_stats (story_uuid, comment_count, updated_at)
+    VALUES (p_story, GREATEST(p_delta, 0), NOW())
+    ON CONFLICT (story_uuid) DO UPDATE
+        SET comment_count = GREATEST(story_comment_stats.comment_count + p_delta, 0),
+            updated_at    = NOW();
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,52 @@
+-- updated_at di story_comment_stats menjadi waktu perubahan terakhir story yang monoton, dasar
+-- Last-Modified & ETag GET /api/comments. MAX(comments.updated_at) bisa mundur saat komentar terbaru
+-- dihapus / disembunyikan sehingga client dengan If-Modified-Since mendapat 304 palsu.
+-- Dinaikkan setiap komentar published ditambah, diedit, berubah status atau dihapus, tidak pernah mundur
+CREATE OR REPLACE FUNCTION bump_story_comment_stats(p_story TEXT, p_delta BIGINT)
+RETURNS VOID AS $$
+BEGIN
+    INSERT INTO story_comment_stats (story_uuid, comment_count, updated_at)
+    VALUES (p_story, GREATEST(p_delta, 0), clock_timestamp())
+    ON CONFLICT (story_uuid) DO UPDATE
+        SET comment_count = GREATEST(story_comment_stats.comment_count + p_delta, 0),
+            updated_at    = GREATEST(story_comment_stats.updated_at, clock_timestamp());
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION sync_story_comment_stats()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Edit di story yang sama: jumlah hanya berubah jika status keluar / masuk published
+    IF TG_OP = 'UPDATE' AND OLD.story_uuid IS NOT DISTINCT FROM NEW.story_uuid THEN
+        IF OLD.status = 'published' OR NEW.status = 'published' THEN
+            PERFORM bump_story_comment_stats(NEW.story_uuid,
+                (NEW.status = 'published')::INT - (OLD.status = 'published')::INT);
+        END IF;
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        IF OLD.status = 'published' THEN
+            PERFORM bump_story_comment_stats(OLD.story_uuid, -1);
+        END IF;
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        IF NEW.status = 'published' THEN
+            PERFORM bump_story_comment_stats(NEW.story_uuid, 1);
+        END IF;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS comments_stats_update ON comments;
+
+-- Semua perubahan yang terlihat di list (konten, content_html, anonimisasi, status) ikut menaikkan updated_at
+CREATE TRIGGER comments_stats_update AFTER UPDATE ON comments FOR EACH ROW
+    WHEN (OLD.* IS DISTINCT FROM NEW.*)
+    EXECUTE PROCEDURE sync_story_comment_stats();
+
+-- Story yang komentar terbarunya lebih baru dari baris stats (data sebelum migrasi ini)
+UPDATE story_comment_stats s SET updated_at = c.last_updated
+FROM (SELECT story_uuid, MAX(updated_at) AS last_updated FROM comments GROUP BY story_uuid) c
+WHERE c.story_uuid = s.story_uuid AND c.last_updated > s.updated_at;
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS comments_stats_update ON comments;
+
+CREATE TRIGGER comments_stats_update AFTER UPDATE ON comments FOR EACH ROW
+    WHEN (OLD.* IS DISTINCT FROM NEW.*)
+    EXECUTE PROCEDURE sync_story_comment_stats();
+
+DROP TRIGGER IF EXISTS update_comments_modtime ON comments;
+
+CREATE TRIGGER update_comments_modtime BEFORE UPDATE ON comments FOR EACH ROW EXECUTE PROCEDURE update_updated_at_column();
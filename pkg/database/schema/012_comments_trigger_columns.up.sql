@@ -0,0 +1,20 @@
+-- Trigger updated_at (001) & stats (011) hanya jalan jika kolom sumber berubah. Update yang hanya
+-- menyentuh content_html (backfill `server content render`) bukan edit dari user: tidak boleh
+-- menandai komentar sebagai diedit atau mengubah Last-Modified / ETag semua story
+DROP TRIGGER IF EXISTS update_comments_modtime ON comments;
+
+CREATE TRIGGER update_comments_modtime BEFORE UPDATE ON comments FOR EACH ROW
+    WHEN (OLD.content IS DISTINCT FROM NEW.content
+        OR OLD.status IS DISTINCT FROM NEW.status
+        OR OLD.user_id IS DISTINCT FROM NEW.user_id
+        OR OLD.story_uuid IS DISTINCT FROM NEW.story_uuid)
+    EXECUTE PROCEDURE update_updated_at_column();
+
+DROP TRIGGER IF EXISTS comments_stats_update ON comments;
+
+CREATE TRIGGER comments_stats_update AFTER UPDATE ON comments FOR EACH ROW
+    WHEN (OLD.content IS DISTINCT FROM NEW.content
+        OR OLD.status IS DISTINCT FROM NEW.status
+        OR OLD.user_id IS DISTINCT FROM NEW.user_id
+        OR OLD.story_uuid IS DISTINCT FROM NEW.story_uuid)
+    EXECUTE PROCEDURE sync_story_comment_stats();
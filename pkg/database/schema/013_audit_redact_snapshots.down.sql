@@ -0,0 +1,2 @@
+-- Redaksi tidak bisa dibatalkan: isi & user_id yang dihapus tidak disimpan di mana pun
+SELECT 1;
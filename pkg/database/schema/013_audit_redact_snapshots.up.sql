@@ -0,0 +1,18 @@
+-- Snapshot komentar di audit log tidak lagi menyimpan isi & user_id (erasure harus benar-benar
+-- menghapus data user), diganti panjang & sha256 isi. Baris lama diredaksi dengan format yang sama.
+-- Trigger append-only dimatikan hanya selama transaksi migrasi ini
+ALTER TABLE audit_events DISABLE TRIGGER audit_events_no_update;
+
+UPDATE audit_events
+SET before = (before - 'content' - 'content_html' - 'user_id') || jsonb_build_object(
+        'content_length', char_length(before->>'content'),
+        'content_sha256', encode(sha256(convert_to(before->>'content', 'UTF8')), 'hex'))
+WHERE target_type = 'comment' AND before ? 'content';
+
+UPDATE audit_events
+SET after = (after - 'content' - 'content_html' - 'user_id') || jsonb_build_object(
+        'content_length', char_length(after->>'content'),
+        'content_sha256', encode(sha256(convert_to(after->>'content', 'UTF8')), 'hex'))
+WHERE target_type = 'comment' AND after ? 'content';
+
+ALTER TABLE audit_events ENABLE TRIGGER audit_events_no_update;
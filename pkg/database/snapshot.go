@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"gorm.io/gorm"
+
+)
+
+// SnapshotLine adalah satu baris file JSONL hasil Snapshot
+type SnapshotLine struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+// Snapshot menulis semua baris dari setiap tabel di schema public ke w (satu JSON per baris).
+// Dipakai sebagai backup sebelum reset, return jumlah baris yang ditulis
+func Snapshot(ctx context.Context, db *gorm.DB, w io.Writer) (int, error) {
+	var tables []string
+	err := db.WithContext(ctx).Raw(`
+		SELECT table_name FROM information_schema.tables
+		WHERE table_schema = 'public' AND table_type = 'BASE TABLE'
+		ORDER BY table_name`).Scan(&tables).Error
+	if err != nil {
+		return 0, err
+	}
+
+	enc := json.NewEncoder(w)
+	written := 0
+
+	for _, table := range tables {
+		rows, err := db.WithContext(ctx).Raw(`SELECT row_to_json(t)::text FROM ` + quoteIdent(table) + ` t`).Rows()
+		if err != nil {
+			return written, err
+		}
+
+		for rows.Next() {
+			var row string
+			if err := rows.Scan(&row); err != nil {
+				rows.Close()
+				return written, err
+			}
+			if err := enc.Encode(SnapshotLine{Table: table, Row: json.RawMessage(row)}); err != nil {
+				rows.Close()
+				return written, err
+			}
+			written++
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return written, err
+		}
+		rows.Close()
+	}
+
+	return written, nil
+}
+
+// quoteIdent meng-quote nama tabel untuk SQL (nama tabel tidak bisa dikirim sebagai parameter)
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"khalif-comment/pkg/logger"
+
+)
+
+// ErrRedisUnavailable dikembalikan tanpa menyentuh jaringan selama circuit breaker terbuka
+var ErrRedisUnavailable = errors.New("redis unavailable")
+
+// metrics dipublikasikan lewat expvar di /internal/debug/vars, contoh: {"redis": {"state": "open", "breaker_opened": 2, ...}}
+var metrics = expvar.NewMap("redis")
+
+// RedisMonitor: Circuit breaker untuk Redis.
+// Breaker terbuka setelah failureThreshold error berturut-turut (dari operasi maupun ping),
+// selama terbuka semua operasi langsung gagal dengan ErrRedisUnavailable sehingga request
+// tidak menunggu timeout. Breaker hanya ditutup kembali oleh ping Monitor yang berhasil
+type RedisMonitor struct {
+	client           *redis.Client
+	failureThreshold int32
+	failures         atomic.Int32
+	open             atomic.Bool
+}
+
+func NewRedisMonitor(client *redis.Client, failureThreshold int) *RedisMonitor {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	m := &RedisMonitor{client: client, failureThreshold: int32(failureThreshold)}
+	metrics.Set("state", expvar.Func(func() any { return m.State() }))
+	return m
+}
+
+// Available: false selama breaker terbuka. Nil monitor dianggap selalu tersedia
+func (m *RedisMonitor) Available() bool {
+	return m == nil || !m.open.Load()
+}
+
+// State: "closed" (normal) atau "open" (degraded)
+func (m *RedisMonitor) State() string {
+	if m.Available() {
+		return "closed"
+	}
+	return "open"
+}
+
+// Report mencatat hasil satu operasi Redis. redis.Nil (key tidak ada) bukan kegagalan
+func (m *RedisMonitor) Report(err error) {
+	if m == nil {
+		return
+	}
+	if err == nil || errors.Is(err, redis.Nil) {
+		m.failures.Store(0)
+		return
+	}
+	if errors.Is(err, ErrRedisUnavailable) || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	metrics.Add("errors", 1)
+	if m.failures.Add(1) >= m.failureThreshold {
+		m.trip(err)
+	}
+}
+
+// Monitor melakukan ping berkala sampai ctx dibatalkan. Ping pertama langsung dijalankan
+// agar Redis yang mati saat startup segera terdeteksi
+func (m *RedisMonitor) Monitor(ctx context.Context, interval time.Duration) {
+	m.ping(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.ping(ctx)
+		}
+	}
+}
+
+func (m *RedisMonitor) ping(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	if err := m.client.Ping(ctx).Err(); err != nil {
+		metrics.Add("ping_failures", 1)
+		// Ping gagal langsung membuka breaker, tidak perlu menunggu threshold
+		m.trip(err)
+		return
+	}
+
+	m.failures.Store(0)
+	if m.open.CompareAndSwap(true, false) {
+		logger.Info("Redis reachable again, cache and distributed rate limiting restored")
+	}
+}
+
+func (m *RedisMonitor) trip(err error) {
+	if m.open.CompareAndSwap(false, true) {
+		metrics.Add("breaker_opened", 1)
+		logger.Error("Redis unavailable, running degraded: cache bypassed, rate limiting per instance", zap.Error(err))
+	}
+}
@@ -0,0 +1,124 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"golang.org/x/text/language"
+
+)
+
+// Bahasa yang didukung, sesuai nama file di locales/
+const (
+	Indonesian = "id"
+	English    = "en"
+)
+
+//go:embed locales/*.json
+var files embed.FS
+
+// catalog: bahasa -> key -> pesan. Key = kode error (COMMENT_NOT_FOUND), kode pesan sukses
+// (COMMENT_DELETED) atau "validation.<tag validator>". Placeholder ditulis {nama}
+var catalog = mustLoad()
+
+var (
+	defaultLang = Indonesian
+	languages   = []string{Indonesian, English}
+	matcher     = newMatcher(languages)
+)
+
+func mustLoad() map[string]map[string]string {
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	out := make(map[string]map[string]string, len(entries))
+	for _, e := range entries {
+		data, err := files.ReadFile(path.Join("locales", e.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: %s: %v", e.Name(), err))
+		}
+		out[strings.TrimSuffix(e.Name(), ".json")] = messages
+	}
+	return out
+}
+
+func newMatcher(langs []string) language.Matcher {
+	tags := make([]language.Tag, len(langs))
+	for i, l := range langs {
+		tags[i] = language.MustParse(l)
+	}
+	return language.NewMatcher(tags)
+}
+
+// SetDefault: Bahasa untuk request tanpa Accept-Language / dengan bahasa yang tidak didukung.
+// Dipanggil sekali saat startup
+func SetDefault(lang string) error {
+	if _, ok := catalog[lang]; !ok {
+		return fmt.Errorf("unsupported language %q", lang)
+	}
+	defaultLang = lang
+	// Matcher memakai bahasa pertama sebagai fallback
+	ordered := []string{lang}
+	for _, l := range languages {
+		if l != lang {
+			ordered = append(ordered, l)
+		}
+	}
+	languages = ordered
+	matcher = newMatcher(ordered)
+	return nil
+}
+
+// Negotiate memilih bahasa yang didukung dari header Accept-Language (q-value & region
+// diperhitungkan, misal "en-US,en;q=0.9" -> en, "id-ID" -> id)
+func Negotiate(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return defaultLang
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return defaultLang
+	}
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return defaultLang
+	}
+	return languages[index]
+}
+
+// Lookup: Pesan untuk key, fallback ke bahasa default lalu English. ok = false jika key tidak ada di katalog
+func Lookup(lang, key string, args map[string]string) (string, bool) {
+	for _, l := range []string{lang, defaultLang, English} {
+		if msg, ok := catalog[l][key]; ok {
+			return format(msg, args), true
+		}
+	}
+	return "", false
+}
+
+// T seperti Lookup, key yang tidak dikenal dikembalikan apa adanya
+func T(lang, key string, args map[string]string) string {
+	if msg, ok := Lookup(lang, key, args); ok {
+		return msg
+	}
+	return key
+}
+
+func format(msg string, args map[string]string) string {
+	if len(args) == 0 {
+		return msg
+	}
+	pairs := make([]string, 0, len(args)*2)
+	for k, v := range args {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
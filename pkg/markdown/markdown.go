@@ -0,0 +1,255 @@
+package markdown
+
+import (
+	"net/url"
+	"strings"
+	"unicode"
+
+)
+
+// Subset Markdown untuk komentar:
+//
+//	**tebal**  *miring* / _miring_  `kode`  [teks](https://...)  ||spoiler||  > kutipan
+//
+// Escape-first: setiap potongan teks dari user di-escape sebelum ditulis, tag & atribut hanya
+// berasal dari renderer ini. Tidak ada HTML mentah, gambar, heading, list, maupun link selain
+// http / https / mailto. Format inline tidak melewati batas baris
+
+const (
+	maxDepth     = 8    // Batas nesting format inline
+	maxURLLength = 2048 // URL lebih panjang dirender sebagai teks biasa
+)
+
+// LinkRel: Atribut rel setiap link, konten user tidak boleh memberi reputasi SEO
+const LinkRel = "nofollow ugc"
+
+// Render mengubah source Markdown menjadi HTML yang aman disisipkan langsung ke halaman
+func Render(src string) string {
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\r", "\n")
+
+	var b strings.Builder
+	var paragraph, quote []string
+	flushParagraph := func() {
+		writeParagraph(&b, paragraph)
+		paragraph = nil
+	}
+	flushQuote := func() {
+		if len(quote) == 0 {
+			return
+		}
+		b.WriteString("<blockquote>")
+		var inner []string
+		for _, line := range quote {
+			if strings.TrimSpace(line) == "" {
+				writeParagraph(&b, inner)
+				inner = nil
+				continue
+			}
+			inner = append(inner, line)
+		}
+		writeParagraph(&b, inner)
+		b.WriteString("</blockquote>")
+		quote = nil
+	}
+
+	for _, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		switch {
+		case strings.HasPrefix(trimmed, ">"):
+			flushParagraph()
+			content := strings.TrimPrefix(trimmed, ">")
+			quote = append(quote, strings.TrimPrefix(content, " "))
+		case strings.TrimSpace(line) == "":
+			flushParagraph()
+			flushQuote()
+		default:
+			flushQuote()
+			paragraph = append(paragraph, line)
+		}
+	}
+	flushParagraph()
+	flushQuote()
+
+	return b.String()
+}
+
+// writeParagraph: Baris berurutan jadi satu <p>, dipisah <br>
+func writeParagraph(b *strings.Builder, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	b.WriteString("<p>")
+	for i, line := range lines {
+		if i > 0 {
+			b.WriteString("<br>")
+		}
+		writeInline(b, strings.TrimSpace(line), 0, false)
+	}
+	b.WriteString("</p>")
+}
+
+// writeInline merender format inline satu baris. inLink = sedang di dalam teks link (link tidak boleh bersarang)
+func writeInline(b *strings.Builder, s string, depth int, inLink bool) {
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			writeEscapedByte(b, s[i+1])
+			i += 2
+			continue
+
+		case c == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end > 0 {
+				b.WriteString("<code>")
+				writeEscaped(b, s[i+1:i+1+end])
+				b.WriteString("</code>")
+				i += end + 2
+				continue
+			}
+
+		case strings.HasPrefix(s[i:], "**"):
+			if n := writeSpan(b, s[i:], "**", "<strong>", "</strong>", depth, inLink); n > 0 {
+				i += n
+				continue
+			}
+
+		case strings.HasPrefix(s[i:], "||"):
+			if n := writeSpan(b, s[i:], "||", `<span class="spoiler">`, "</span>", depth, inLink); n > 0 {
+				i += n
+				continue
+			}
+
+		case c == '*':
+			if n := writeSpan(b, s[i:], "*", "<em>", "</em>", depth, inLink); n > 0 {
+				i += n
+				continue
+			}
+
+		case c == '_' && (i == 0 || !isWordByte(s[i-1])):
+			// snake_case dan sejenisnya bukan italic
+			if end := closingIndex(s[i+1:], "_"); end > 0 && (i+end+2 >= len(s) || !isWordByte(s[i+end+2])) {
+				if n := writeSpan(b, s[i:], "_", "<em>", "</em>", depth, inLink); n > 0 {
+					i += n
+					continue
+				}
+			}
+
+		case c == '[' && !inLink:
+			if n := writeLink(b, s[i:], depth); n > 0 {
+				i += n
+				continue
+			}
+		}
+
+		writeEscapedByte(b, c)
+		i++
+	}
+}
+
+// writeSpan: Format dengan pembuka & penutup yang sama. Return jumlah byte yang dipakai, 0 = bukan format
+func writeSpan(b *strings.Builder, s, delim, open, close string, depth int, inLink bool) int {
+	if depth >= maxDepth {
+		return 0
+	}
+	end := closingIndex(s[len(delim):], delim)
+	if end <= 0 {
+		return 0
+	}
+	b.WriteString(open)
+	writeInline(b, s[len(delim):len(delim)+end], depth+1, inLink)
+	b.WriteString(close)
+	return len(delim)*2 + end
+}
+
+// closingIndex: Posisi penutup delim, isi tidak boleh kosong atau diapit spasi ("2 * 3 * 4" bukan italic)
+func closingIndex(s, delim string) int {
+	end := strings.Index(s, delim)
+	if end <= 0 {
+		return -1
+	}
+	inner := s[:end]
+	if unicode.IsSpace(rune(inner[0])) || unicode.IsSpace(rune(inner[len(inner)-1])) {
+		return -1
+	}
+	return end
+}
+
+// writeLink: [teks](url). URL yang tidak aman membuat seluruh potongan dirender sebagai teks biasa
+func writeLink(b *strings.Builder, s string, depth int) int {
+	textEnd := strings.IndexByte(s, ']')
+	if textEnd <= 1 || textEnd+1 >= len(s) || s[textEnd+1] != '(' || strings.IndexByte(s[1:textEnd], '[') >= 0 {
+		return 0
+	}
+	urlEnd := strings.IndexByte(s[textEnd+2:], ')')
+	if urlEnd <= 0 {
+		return 0
+	}
+	href := s[textEnd+2 : textEnd+2+urlEnd]
+	if !SafeURL(href) {
+		return 0
+	}
+
+	b.WriteString(`<a href="`)
+	writeEscaped(b, href)
+	b.WriteString(`" rel="` + LinkRel + `">`)
+	writeInline(b, s[1:textEnd], depth+1, true)
+	b.WriteString("</a>")
+	return textEnd + 2 + urlEnd + 1
+}
+
+// SafeURL: Hanya URL absolut http(s) dengan host, atau mailto. Spasi & karakter kontrol ditolak
+// agar "java\tscript:" dan sejenisnya tidak lolos
+func SafeURL(raw string) bool {
+	if raw == "" || len(raw) > maxURLLength {
+		return false
+	}
+	for _, r := range raw {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || r == '"' || r == '\'' || r == '<' || r == '>' || r == '`' {
+			return false
+		}
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != "" && strings.HasPrefix(strings.ToLower(raw), strings.ToLower(u.Scheme)+"://")
+	case "mailto":
+		return u.Opaque != ""
+	}
+	return false
+}
+
+func writeEscaped(b *strings.Builder, s string) {
+	for i := 0; i < len(s); i++ {
+		writeEscapedByte(b, s[i])
+	}
+}
+
+// writeEscapedByte: Byte non-ASCII (bagian rune UTF-8) ditulis apa adanya
+func writeEscapedByte(b *strings.Builder, c byte) {
+	switch c {
+	case '&':
+		b.WriteString("&amp;")
+	case '<':
+		b.WriteString("&lt;")
+	case '>':
+		b.WriteString("&gt;")
+	case '"':
+		b.WriteString("&#34;")
+	case '\'':
+		b.WriteString("&#39;")
+	default:
+		b.WriteByte(c)
+	}
+}
+
+func isASCIIPunct(c byte) bool {
+	return c < 0x80 && unicode.IsPunct(rune(c)) || c == '`' || c == '|' || c == '>' || c == '<'
+}
+
+func isWordByte(c byte) bool {
+	return c >= 0x80 || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
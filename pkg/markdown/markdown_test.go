@@ -0,0 +1,159 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+)
+
+func TestRender(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"plain", "halo dunia", "<p>halo dunia</p>"},
+		{"empty", "", ""},
+		{"bold", "ini **tebal**", "<p>ini <strong>tebal</strong></p>"},
+		{"italic star", "ini *miring*", "<p>ini <em>miring</em></p>"},
+		{"italic underscore", "ini _miring_", "<p>ini <em>miring</em></p>"},
+		{"snake case", "pakai snake_case_name saja", "<p>pakai snake_case_name saja</p>"},
+		{"spaced star", "2 * 3 * 4", "<p>2 * 3 * 4</p>"},
+		{"nested", "**tebal _dan miring_**", "<p><strong>tebal <em>dan miring</em></strong></p>"},
+		{"code", "jalankan `go test ./...`", "<p>jalankan <code>go test ./...</code></p>"},
+		{"code keeps markdown", "`**bukan tebal**`", "<p><code>**bukan tebal**</code></p>"},
+		{"spoiler", "endingnya ||dia selamat||", `<p>endingnya <span class="spoiler">dia selamat</span></p>`},
+		{"link", "[baca](https://example.com/a?b=1&c=2)", `<p><a href="https://example.com/a?b=1&amp;c=2" rel="nofollow ugc">baca</a></p>`},
+		{"mailto", "[email](mailto:a@example.com)", `<p><a href="mailto:a@example.com" rel="nofollow ugc">email</a></p>`},
+		{"formatted link text", "[**tebal**](https://example.com)", `<p><a href="https://example.com" rel="nofollow ugc"><strong>tebal</strong></a></p>`},
+		{"escaped delimiter", `\*bukan miring\*`, "<p>*bukan miring*</p>"},
+		{"line break", "baris satu\nbaris dua", "<p>baris satu<br>baris dua</p>"},
+		{"crlf", "satu\r\ndua", "<p>satu<br>dua</p>"},
+		{"paragraphs", "satu\n\ndua", "<p>satu</p><p>dua</p>"},
+		{"blockquote", "> kutipan\n> lanjut\n\nbalasan", "<blockquote><p>kutipan<br>lanjut</p></blockquote><p>balasan</p>"},
+		{"quote then text", "> kutipan\nbalasan", "<blockquote><p>kutipan</p></blockquote><p>balasan</p>"},
+		{"unclosed", "**belum ditutup", "<p>**belum ditutup</p>"},
+		{"emoji", "keren 👍 **mantap**", "<p>keren 👍 <strong>mantap</strong></p>"},
+		{"no cross line", "**awal\nakhir**", "<p>**awal<br>akhir**</p>"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Render(tc.src); got != tc.want {
+				t.Errorf("Render(%q)\n got  %s\n want %s", tc.src, got, tc.want)
+			}
+		})
+	}
+}
+
+// XSS vectors dari OWASP cheat sheet & kasus khusus parser ini. Output tidak boleh mengandung
+// tag / atribut di luar allowlist, dan href hanya boleh http(s) / mailto
+var xssVectors = []string{
+	`<script>alert(1)</script>`,
+	`<img src=x onerror=alert(1)>`,
+	`<svg/onload=alert(1)>`,
+	`<iframe src="javascript:alert(1)"></iframe>`,
+	`<a href="javascript:alert(1)">x</a>`,
+	`"><script>alert(1)</script>`,
+	`'><img src=x onerror=alert(1)>`,
+	`[x](javascript:alert(1))`,
+	`[x](JaVaScRiPt:alert(1))`,
+	`[x](  javascript:alert(1))`,
+	"[x](java\tscript:alert(1))",
+	"[x](java\x00script:alert(1))",
+	`[x](jav&#x61;script:alert(1))`,
+	`[x](&#106;avascript:alert(1))`,
+	`[x](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)`,
+	`[x](vbscript:msgbox(1))`,
+	`[x](//evil.example/steal)`,
+	`[x](/relative/path)`,
+	`[x](http:/\evil.example)`,
+	`[x](https://example.com" onmouseover="alert(1))`,
+	`[x](https://example.com'onmouseover='alert(1))`,
+	`[x](https://example.com><script>alert(1)</script>)`,
+	"[x](https://example.com`onmouseover=alert(1))",
+	`[<img src=x onerror=alert(1)>](https://example.com)`,
+	`[x](https://example.com)<script>alert(1)</script>`,
+	`**<script>alert(1)</script>**`,
+	`*<b onclick=alert(1)>x</b>*`,
+	"`<script>alert(1)</script>`",
+	`||<img src=x onerror=alert(1)>||`,
+	`> <script>alert(1)</script>`,
+	`&lt;script&gt;alert(1)&lt;/script&gt;`,
+	`<scr<script>ipt>alert(1)</script>`,
+	`<<script>script>alert(1)<</script>/script>`,
+	`\<script\>alert(1)\</script\>`,
+	`[[x](javascript:alert(1))](https://example.com)`,
+	`[x](https://example.com)[y](javascript:alert(1))`,
+	`<math><mtext><table><mglyph><style><img src=x onerror=alert(1)>`,
+	`<div style="background:url(javascript:alert(1))">`,
+	"<scr\u0000ipt>alert(1)</scr\u0000ipt>",
+	`<a href="&#x6A;avascript:alert(1)">x</a>`,
+	`[x](https://example.com/<script>)`,
+	strings.Repeat("**", 5000) + "<script>alert(1)</script>",
+	strings.Repeat("[", 2000) + "x](javascript:alert(1))",
+}
+
+var (
+	tagPattern  = regexp.MustCompile(`<(/?)([a-zA-Z0-9]+)([^>]*)>`)
+	attrPattern = regexp.MustCompile(`\s+([a-z]+)="([^"]*)"`)
+	allowedTags = map[string]bool{"p": true, "br": true, "strong": true, "em": true, "code": true, "blockquote": true, "a": true, "span": true}
+)
+
+func TestRenderXSS(t *testing.T) {
+	for _, src := range xssVectors {
+		out := Render(src)
+
+		for _, m := range tagPattern.FindAllStringSubmatch(out, -1) {
+			tag, attrs := strings.ToLower(m[2]), m[3]
+			if !allowedTags[tag] {
+				t.Errorf("Render(%q) produced disallowed tag <%s>: %s", src, tag, out)
+				continue
+			}
+			// Sisa atribut setelah atribut yang dikenal harus kosong (tidak ada onerror=, style=, dst)
+			rest := attrPattern.ReplaceAllStringFunc(attrs, func(a string) string {
+				sub := attrPattern.FindStringSubmatch(a)
+				name, value := sub[1], sub[2]
+				switch {
+				case tag == "a" && name == "href":
+					if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") && !strings.HasPrefix(value, "mailto:") {
+						t.Errorf("Render(%q) produced unsafe href %q", src, value)
+					}
+				case tag == "a" && name == "rel":
+					if value != LinkRel {
+						t.Errorf("Render(%q) produced rel %q", src, value)
+					}
+				case tag == "span" && name == "class" && value == "spoiler":
+				default:
+					t.Errorf("Render(%q) produced disallowed attribute %s on <%s>", src, name, tag)
+				}
+				return ""
+			})
+			if strings.TrimSpace(rest) != "" {
+				t.Errorf("Render(%q) produced unexpected attribute text %q: %s", src, rest, out)
+			}
+		}
+
+		// Teks di luar tag tidak boleh berisi < atau > mentah
+		text := tagPattern.ReplaceAllString(out, "")
+		if strings.ContainsAny(text, "<>") {
+			t.Errorf("Render(%q) left raw angle bracket: %s", src, out)
+		}
+	}
+}
+
+func TestSafeURL(t *testing.T) {
+	safe := []string{"https://example.com", "http://example.com/a?b=c#d", "HTTPS://EXAMPLE.COM", "mailto:a@example.com"}
+	unsafe := []string{"", "javascript:alert(1)", "//example.com", "/path", "https://", "http:example.com", "ftp://example.com",
+		"data:text/html,x", "https://exa mple.com", "https://example.com\n", "mailto:", strings.Repeat("a", maxURLLength) + "https://x"}
+	for _, u := range safe {
+		if !SafeURL(u) {
+			t.Errorf("SafeURL(%q) = false, want true", u)
+		}
+	}
+	for _, u := range unsafe {
+		if SafeURL(u) {
+			t.Errorf("SafeURL(%q) = true, want false", u)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"khalif-comment/pkg/logger"
+	"khalif-comment/pkg/requestctx"
+	"khalif-comment/pkg/utils"
+
+)
+
+// ServiceIdentityKey: Key gin context untuk *ServiceIdentity hasil APIKeyAuth
+const ServiceIdentityKey = "service_identity"
+
+// ServiceIdentity: Service pemanggil endpoint /internal
+type ServiceIdentity struct {
+	Service   string
+	KeyPrefix string // Bagian publik API key, aman ditulis ke log
+	Scopes    []string
+}
+
+func (s *ServiceIdentity) HasScope(scope string) bool {
+	return slices.Contains(s.Scopes, scope)
+}
+
+// ServiceAuthenticator memvalidasi API key plaintext, error apapun = 401
+type ServiceAuthenticator func(ctx context.Context, apiKey string) (*ServiceIdentity, error)
+
+// APIKeyAuth: Autentikasi service-to-service lewat header X-API-Key.
+// Setiap request dicatat ke log audit beserta nama service pemanggil
+func APIKeyAuth(authenticate ServiceAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := c.GetHeader("X-API-Key")
+		if apiKey == "" {
+			utils.AbortWithError(c, http.StatusUnauthorized, utils.CodeUnauthorized, "Unauthorized")
+			return
+		}
+
+		identity, err := authenticate(c.Request.Context(), apiKey)
+		if err != nil {
+			logger.Info("Internal API rejected",
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("ip", c.ClientIP()),
+				zap.Error(err),
+			)
+			utils.AbortWithError(c, http.StatusUnauthorized, utils.CodeInvalidAPIKey, "Invalid API Key")
+			return
+		}
+
+		c.Set(ServiceIdentityKey, identity)
+		c.Request = c.Request.WithContext(requestctx.WithActor(c.Request.Context(), requestctx.ActorService, identity.Service, ""))
+		start := time.Now()
+
+		c.Next()
+
+		logger.Info("Internal API call",
+			zap.String("service", identity.Service),
+			zap.String("key_prefix", identity.KeyPrefix),
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("request_id", c.GetString("request_id")),
+			zap.Int("status", c.Writer.Status()),
+			zap.Duration("latency", time.Since(start)),
+		)
+	}
+}
+
+// RequireScope: Dipasang setelah APIKeyAuth, key harus punya scope tertentu
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if identity := ServiceIdentityFrom(c); identity != nil && identity.HasScope(scope) {
+			c.Next()
+			return
+		}
+		utils.AbortWithError(c, http.StatusForbidden, utils.CodeForbidden, "Forbidden")
+	}
+}
+
+// ServiceIdentityFrom: Service pemanggil request saat ini, nil jika bukan request internal
+func ServiceIdentityFrom(c *gin.Context) *ServiceIdentity {
+	if value, ok := c.Get(ServiceIdentityKey); ok {
+		if identity, ok := value.(*ServiceIdentity); ok {
+			return identity
+		}
+	}
+	return nil
+}
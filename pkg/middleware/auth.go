@@ -1,43 +1,130 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
+
+	"khalif-comment/pkg/auth"
+	"khalif-comment/pkg/health"
+	"khalif-comment/pkg/requestctx"
+	"khalif-comment/pkg/utils"
 
 )
 
-func AuthMiddleware(secretKey string) gin.HandlerFunc {
+// PrincipalKey: Key gin context untuk *auth.Principal hasil verifikasi token.
+// "user_id" & "role" tetap di-set sebagai string untuk handler yang memakai c.GetString
+const PrincipalKey = "principal"
+
+// AuthMiddleware memverifikasi token lalu mengecek daftar revocation (logout / ban dari service auth).
+// revocations boleh nil (tanpa pengecekan revocation, dipakai CLI / test)
+func AuthMiddleware(verifier *auth.Verifier, revocations *auth.Revocations) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if !strings.Contains(authHeader, "Bearer") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		tokenString, ok := bearerToken(c)
+		if !ok {
+			utils.AbortWithError(c, http.StatusUnauthorized, utils.CodeUnauthorized, "Unauthorized")
 			return
 		}
 
-		tokenString := strings.Split(authHeader, " ")[1]
+		principal, ok := authenticate(c, verifier, revocations, tokenString)
+		if !ok {
+			return
+		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method")
-			}
-			return []byte(secretKey), nil
-		})
+		setPrincipal(c, principal)
+		c.Next()
+	}
+}
+
+// OptionalAuth: Untuk endpoint publik yang hasilnya bergantung pada siapa yang melihat
+// (misal pemilik / moderator bisa melihat komentar hidden). Tanpa header = anonim
+func OptionalAuth(verifier *auth.Verifier, revocations *auth.Revocations) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := bearerToken(c)
+		if !ok {
+			c.Next()
+			return
+		}
 
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid Token"})
+		principal, ok := authenticate(c, verifier, revocations, tokenString)
+		if !ok {
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			c.Set("user_id", claims["user_id"])
-			c.Set("role", claims["role"])
-			c.Next()
-		} else {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid Token Claims"})
+		setPrincipal(c, principal)
+		c.Next()
+	}
+}
+
+// RequireRole: Dipasang setelah AuthMiddleware, hanya role tertentu yang boleh lanjut
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
 		}
+		utils.AbortWithError(c, http.StatusForbidden, utils.CodeForbidden, "Forbidden")
+	}
+}
+
+// PrincipalFrom: Principal request saat ini, nil jika anonim
+func PrincipalFrom(c *gin.Context) *auth.Principal {
+	if value, ok := c.Get(PrincipalKey); ok {
+		if principal, ok := value.(*auth.Principal); ok {
+			return principal
+		}
+	}
+	return nil
+}
+
+// bearerToken: Ambil token dari header "Authorization: Bearer <token>". Header tanpa token dianggap tidak ada
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(c.GetHeader("Authorization")), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// authenticate: Verifikasi + cek revocation. Jika gagal, response error sudah dikirim
+func authenticate(c *gin.Context, verifier *auth.Verifier, revocations *auth.Revocations, tokenString string) (*auth.Principal, bool) {
+	principal, err := verifier.Verify(tokenString)
+	if err == nil {
+		err = revocations.Check(c.Request.Context(), principal)
+	}
+	switch {
+	case err == nil:
+		return principal, true
+	case errors.Is(err, health.ErrRedisUnavailable):
+		// Hanya terjadi jika REVOCATION_FAIL_CLOSED aktif
+		utils.AbortWithError(c, http.StatusServiceUnavailable, utils.CodeAuthUnavailable, "Authentication temporarily unavailable")
+	default:
+		code, message := tokenError(err)
+		utils.AbortWithError(c, http.StatusUnauthorized, code, message)
+	}
+	return nil, false
+}
+
+func setPrincipal(c *gin.Context, principal *auth.Principal) {
+	c.Set(PrincipalKey, principal)
+	c.Set("user_id", principal.UserID)
+	c.Set("role", principal.Role)
+	c.Request = c.Request.WithContext(requestctx.WithActor(c.Request.Context(), requestctx.ActorUser, principal.UserID, principal.Role))
+}
+
+// tokenError: Kode & pesan error untuk client, detail validasi tidak dibocorkan
+func tokenError(err error) (string, string) {
+	if errors.Is(err, auth.ErrTokenExpired) {
+		return utils.CodeTokenExpired, "Token Expired"
+	}
+	if errors.Is(err, auth.ErrTokenRevoked) {
+		return utils.CodeTokenRevoked, "Token Revoked"
 	}
-}
\ No newline at end of file
+	return utils.CodeInvalidToken, "Invalid Token"
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+)
+
+func TestBearerToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		header string
+		want   string
+		wantOK bool
+	}{
+		{"", "", false},
+		{"Bearer", "", false}, // Dulu panic: index out of range
+		{"Bearer ", "", false},
+		{"Bearer    ", "", false},
+		{"Bearer abc.def.ghi", "abc.def.ghi", true},
+		{"bearer abc", "abc", true},
+		{"BEARER abc", "abc", true},
+		{"  Bearer   abc  ", "abc", true},
+		{"Basic dXNlcjpwYXNz", "", false},
+		{"Bearerabc", "", false},
+		{"abc", "", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.header, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Request.Header.Set("Authorization", tc.header)
+
+			got, ok := bearerToken(c)
+			if got != tc.want || ok != tc.wantOK {
+				t.Errorf("bearerToken(%q) = %q, %v; want %q, %v", tc.header, got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"khalif-comment/pkg/logger"
+	"khalif-comment/pkg/utils"
+
+)
+
+// HTTPError: Error yang aman ditampilkan ke client apa adanya (domain.Error)
+type HTTPError interface {
+	error
+	ErrorCode() string
+	HTTPStatus() int
+}
+
+// fieldErrors: HTTPError yang membawa detail field tidak valid (domain.Error.WithFields)
+type fieldErrors interface {
+	EachField(fn func(field, code string, params map[string]string))
+}
+
+// ErrorHandler merender error yang dilaporkan handler lewat c.Error(err) sebagai utils.APIResponse:
+//   - HTTPError (bisa terbungkus %w) -> status, kode & pesannya sendiri, plus error per field jika ada
+//   - gin.ErrorTypeBind (body / query tidak valid) -> 400 INVALID_REQUEST dengan error per field
+//   - selain itu dicatat lengkap lalu disamarkan jadi 500 agar detail DB / Redis tidak bocor
+//
+// Pesan diterjemahkan sesuai Accept-Language (lihat pkg/i18n)
+func ErrorHandler() gin.HandlerFunc {
+	utils.UseJSONFieldNames()
+
+	return func(c *gin.Context) {
+		c.Next()
+
+		last := c.Errors.Last()
+		if last == nil || c.Writer.Written() {
+			return
+		}
+
+		var httpErr HTTPError
+		switch {
+		case errors.As(last.Err, &httpErr):
+			var fields []utils.FieldError
+			if fe, ok := httpErr.(fieldErrors); ok {
+				fe.EachField(func(field, code string, params map[string]string) {
+					fields = append(fields, utils.NewFieldError(c, field, code, params))
+				})
+			}
+			utils.ErrorResponseWithFields(c, httpErr.HTTPStatus(), httpErr.ErrorCode(), httpErr.Error(), fields)
+		case last.IsType(gin.ErrorTypeBind):
+			utils.ValidationErrorResponse(c, last.Err)
+		default:
+			logger.Error("Unhandled request error",
+				zap.String("method", c.Request.Method),
+				zap.String("path", c.Request.URL.Path),
+				zap.String("request_id", c.GetString("request_id")),
+				zap.Error(last.Err),
+			)
+			utils.ErrorResponseWithCode(c, http.StatusInternalServerError, utils.CodeInternal, "internal server error")
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"khalif-comment/internal/domain"
+	"khalif-comment/pkg/logger"
+	"khalif-comment/pkg/middleware"
+	"khalif-comment/pkg/utils"
+
+)
+
+type bindBody struct {
+	Content string `json:"content" binding:"required,max=5"`
+	Limit   int    `json:"limit"`
+}
+
+func TestErrorHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Log = zap.NewNop()
+
+	bind := func(c *gin.Context) {
+		var body bindBody
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.Error(err).SetType(gin.ErrorTypeBind)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+	fail := func(err error) gin.HandlerFunc {
+		return func(c *gin.Context) { c.Error(err) }
+	}
+
+	cases := []struct {
+		name       string
+		handler    gin.HandlerFunc
+		body       string
+		lang       string
+		wantStatus int
+		want       utils.APIResponse
+	}{
+		{
+			name:       "domain error",
+			handler:    fail(domain.ErrCommentNotFound),
+			lang:       "en",
+			wantStatus: http.StatusNotFound,
+			want:       utils.APIResponse{Error: "Comment not found", ErrorCode: "COMMENT_NOT_FOUND"},
+		},
+		{
+			name:       "domain error translated",
+			handler:    fail(domain.ErrCommentNotFound),
+			lang:       "id-ID,id;q=0.9",
+			wantStatus: http.StatusNotFound,
+			want:       utils.APIResponse{Error: "Komentar tidak ditemukan", ErrorCode: "COMMENT_NOT_FOUND"},
+		},
+		{
+			name:       "wrapped derived error",
+			handler:    fail(fmt.Errorf("list comments: %w", domain.ErrInvalidCursor)),
+			lang:       "en",
+			wantStatus: http.StatusBadRequest,
+			want:       utils.APIResponse{Error: "Invalid cursor", ErrorCode: "INVALID_CURSOR"},
+		},
+		{
+			name: "domain error with fields",
+			handler: fail(domain.ErrInvalidCursor.WithFields(
+				domain.FieldError{Field: "content", Code: "max", Params: map[string]string{"param": "5"}},
+			)),
+			lang:       "en",
+			wantStatus: http.StatusBadRequest,
+			want: utils.APIResponse{Error: "Invalid cursor", ErrorCode: "INVALID_CURSOR", Fields: []utils.FieldError{
+				{Field: "content", Code: "max", Message: "content must be at most 5"},
+			}},
+		},
+		{
+			name:       "bind validation",
+			handler:    bind,
+			body:       `{"content": ""}`,
+			lang:       "en",
+			wantStatus: http.StatusBadRequest,
+			want: utils.APIResponse{Error: "Invalid request body", ErrorCode: utils.CodeInvalidRequest, Fields: []utils.FieldError{
+				{Field: "content", Code: "required", Message: "content is required"},
+			}},
+		},
+		{
+			name:       "bind type error",
+			handler:    bind,
+			body:       `{"content": "a", "limit": "x"}`,
+			lang:       "en",
+			wantStatus: http.StatusBadRequest,
+			want: utils.APIResponse{Error: "Invalid request body", ErrorCode: utils.CodeInvalidRequest, Fields: []utils.FieldError{
+				{Field: "limit", Code: "type", Message: "limit has the wrong type"},
+			}},
+		},
+		{
+			name:       "bind malformed json",
+			handler:    bind,
+			body:       `{"content":`,
+			lang:       "en",
+			wantStatus: http.StatusBadRequest,
+			want:       utils.APIResponse{Error: "Invalid request body", ErrorCode: utils.CodeInvalidRequest},
+		},
+		{
+			name:       "unknown error masked",
+			handler:    fail(errors.New(`pq: relation "comments" does not exist`)),
+			lang:       "en",
+			wantStatus: http.StatusInternalServerError,
+			want:       utils.APIResponse{Error: "Internal server error", ErrorCode: utils.CodeInternal},
+		},
+		{
+			name:       "last error wins",
+			handler:    func(c *gin.Context) { c.Error(errors.New("db down")); c.Error(domain.ErrCommentNotFound) },
+			lang:       "en",
+			wantStatus: http.StatusNotFound,
+			want:       utils.APIResponse{Error: "Comment not found", ErrorCode: "COMMENT_NOT_FOUND"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(middleware.ErrorHandler())
+			r.POST("/", tc.handler)
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", tc.lang)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tc.wantStatus, w.Body)
+			}
+			var got utils.APIResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode body %q: %v", w.Body, err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("body = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// Response yang sudah ditulis handler tidak boleh ditimpa
+func TestErrorHandlerKeepsWrittenResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger.Log = zap.NewNop()
+
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, "partial")
+		c.Error(errors.New("stream failed"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Errorf("got %d %q, want 200 \"partial\"", w.Code, w.Body)
+	}
+}
@@ -21,20 +21,20 @@ func Logger() gin.HandlerFunc {
 		end := time.Now()
 		latency := end.Sub(start)
 
+		fields := []zap.Field{
+			zap.Int("status", c.Writer.Status()),
+			zap.String("method", c.Request.Method),
+			zap.String("path", path),
+			zap.String("query", query),
+			zap.String("ip", c.ClientIP()),
+			zap.String("request_id", c.GetString("request_id")),
+			zap.Duration("latency", latency),
+			zap.String("user-agent", c.Request.UserAgent()),
+		}
+		// Error tak dikenal sudah dicatat lengkap oleh ErrorHandler, di sini cukup ringkasannya
 		if len(c.Errors) > 0 {
-			for _, e := range c.Errors.Errors() {
-				logger.Error(e)
-			}
-		} else {
-			logger.Info("Incoming Request",
-				zap.Int("status", c.Writer.Status()),
-				zap.String("method", c.Request.Method),
-				zap.String("path", path),
-				zap.String("query", query),
-				zap.String("ip", c.ClientIP()),
-				zap.Duration("latency", latency),
-				zap.String("user-agent", c.Request.UserAgent()),
-			)
+			fields = append(fields, zap.Strings("errors", c.Errors.Errors()))
 		}
+		logger.Info("Incoming Request", fields...)
 	}
 }
\ No newline at end of file
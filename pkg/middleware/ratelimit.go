@@ -2,50 +2,190 @@ package middleware
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 
+	"khalif-comment/pkg/health"
+	"khalif-comment/pkg/utils"
+
 )
 
-type RateLimitConfig struct {
+// RateLimitPolicy: Batas request untuk satu route group. Counter tiap policy terpisah,
+// jadi limit write yang ketat tidak memakan jatah read
+type RateLimitPolicy struct {
+	Name   string
 	Limit  int
 	Window time.Duration
 }
 
-func RateLimit(rdb *redis.Client, config RateLimitConfig) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		ip := c.ClientIP()
-		key := fmt.Sprintf("rl:%s", ip)
+// ParseRateLimitPolicy membaca format "<limit>/<window>", contoh: "300/1m", "10/30s"
+func ParseRateLimitPolicy(name, spec string) (RateLimitPolicy, error) {
+	limitStr, windowStr, ok := strings.Cut(spec, "/")
+	if !ok {
+		return RateLimitPolicy{}, fmt.Errorf("rate limit %s: expected <limit>/<window>, got %q", name, spec)
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 {
+		return RateLimitPolicy{}, fmt.Errorf("rate limit %s: invalid limit %q", name, limitStr)
+	}
+	window, err := time.ParseDuration(windowStr)
+	if err != nil || window < time.Second {
+		return RateLimitPolicy{}, fmt.Errorf("rate limit %s: invalid window %q", name, windowStr)
+	}
+	return RateLimitPolicy{Name: name, Limit: limit, Window: window}, nil
+}
 
-		count, err := rdb.Incr(c.Request.Context(), key).Result()
-		if err != nil {
-			c.Next()
-			return
-		}
+// slidingWindowScript: Sliding window counter (dua fixed window berbobot), atomik dalam satu EVAL.
+// Waktu diambil dari Redis TIME supaya semua instance memakai jam yang sama.
+// KEYS[1] memakai hash tag {..} agar key window turunan berada di slot cluster yang sama.
+// Return: {allowed, remaining, retry_after_ms, reset_ms}
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local idx = math.floor(now / window)
+local elapsed = now - idx * window
+local reset = window - elapsed
+
+local currKey = KEYS[1] .. ':' .. idx
+local prevKey = KEYS[1] .. ':' .. (idx - 1)
+local curr = tonumber(redis.call('GET', currKey) or '0')
+local prev = tonumber(redis.call('GET', prevKey) or '0')
 
-		if count == 1 {
-			rdb.Expire(c.Request.Context(), key, config.Window)
+local count = prev * (window - elapsed) / window + curr
+if count + 1 > limit then
+	local retry = reset
+	if curr + 1 <= limit and prev > 0 then
+		-- Cukup tunggu bobot window sebelumnya meluruh sampai ada sisa satu slot
+		retry = math.ceil(reset - (limit - curr - 1) * window / prev)
+		if retry < 1 then retry = 1 end
+	end
+	return {0, 0, retry, reset}
+end
+
+redis.call('INCR', currKey)
+redis.call('PEXPIRE', currKey, window * 2)
+return {1, math.floor(limit - count - 1), 0, reset}
+`)
+
+// rateLimitResult: Hasil pengecekan satu request
+type rateLimitResult struct {
+	allowed    bool
+	remaining  int64
+	retryAfter time.Duration
+	reset      time.Duration
+}
+
+// RateLimit membatasi request per user (jika login) atau per IP dengan sliding window di Redis.
+// Pasang setelah AuthMiddleware / OptionalAuth agar request yang login dihitung per user.
+// Selama Redis down (breaker terbuka) atau script gagal, limiter turun ke counter
+// in-memory per instance, bukan membiarkan semua request lolos
+func RateLimit(rdb *redis.Client, monitor *health.RedisMonitor, policy RateLimitPolicy) gin.HandlerFunc {
+	fallback := newLocalLimiter(policy.Window)
+
+	return func(c *gin.Context) {
+		subject := "ip:" + c.ClientIP()
+		if userID := c.GetString("user_id"); userID != "" {
+			subject = "user:" + userID
 		}
+		key := fmt.Sprintf("rl:{%s:%s}", policy.Name, subject)
 
-		remaining := int64(config.Limit) - count
-		if remaining < 0 {
-			remaining = 0
+		var result rateLimitResult
+		var err error = health.ErrRedisUnavailable
+		if monitor.Available() {
+			result, err = runSlidingWindow(c, rdb, key, policy)
+			monitor.Report(err)
+		}
+		if err != nil {
+			result = fallback.allow(key, policy.Limit, time.Now())
 		}
 
-		c.Header("X-RateLimit-Limit", strconv.Itoa(config.Limit))
-		c.Header("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+		c.Header("X-RateLimit-Limit", strconv.Itoa(policy.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(result.remaining, 10))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(ceilSeconds(result.reset), 10))
 
-		if count > int64(config.Limit) {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-				"error": "Too many requests. Please slow down.",
-			})
+		if !result.allowed {
+			c.Header("Retry-After", strconv.FormatInt(ceilSeconds(result.retryAfter), 10))
+			utils.AbortWithError(c, http.StatusTooManyRequests, utils.CodeRateLimited, "Too many requests. Please slow down.")
 			return
 		}
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+func runSlidingWindow(c *gin.Context, rdb *redis.Client, key string, policy RateLimitPolicy) (rateLimitResult, error) {
+	values, err := slidingWindowScript.Run(c.Request.Context(), rdb, []string{key}, policy.Limit, policy.Window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return rateLimitResult{}, err
+	}
+	if len(values) != 4 {
+		return rateLimitResult{}, fmt.Errorf("rate limit script: unexpected reply %v", values)
+	}
+	return rateLimitResult{
+		allowed:    values[0] == 1,
+		remaining:  values[1],
+		retryAfter: time.Duration(values[2]) * time.Millisecond,
+		reset:      time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
+// ceilSeconds: Header Retry-After & X-RateLimit-Reset dalam detik, dibulatkan ke atas
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+// localLimiter: Fixed window per key di memori instance, fallback saat Redis down.
+// Limit efektif menjadi Limit x jumlah instance, cukup untuk menahan abuse selama degraded
+type localLimiter struct {
+	mu        sync.Mutex
+	window    time.Duration
+	counts    map[string]*localWindow
+	lastSweep time.Time
+}
+
+type localWindow struct {
+	count   int64
+	resetAt time.Time
+}
+
+func newLocalLimiter(window time.Duration) *localLimiter {
+	return &localLimiter{window: window, counts: map[string]*localWindow{}, lastSweep: time.Now()}
+}
+
+func (l *localLimiter) allow(key string, limit int, now time.Time) rateLimitResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Bersihkan window yang sudah lewat sekali per window agar map tidak tumbuh tanpa batas
+	if now.Sub(l.lastSweep) > l.window {
+		for k, w := range l.counts {
+			if !now.Before(w.resetAt) {
+				delete(l.counts, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	w, ok := l.counts[key]
+	if !ok || !now.Before(w.resetAt) {
+		w = &localWindow{resetAt: now.Add(l.window)}
+		l.counts[key] = w
+	}
+
+	reset := w.resetAt.Sub(now)
+	if w.count >= int64(limit) {
+		return rateLimitResult{allowed: false, retryAfter: reset, reset: reset}
+	}
+	w.count++
+	return rateLimitResult{allowed: true, remaining: int64(limit) - w.count, reset: reset}
+}
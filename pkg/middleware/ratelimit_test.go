@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+)
+
+func TestParseRateLimitPolicy(t *testing.T) {
+	cases := []struct {
+		spec    string
+		want    RateLimitPolicy
+		wantErr bool
+	}{
+		{"300/1m", RateLimitPolicy{Name: "read", Limit: 300, Window: time.Minute}, false},
+		{"10/30s", RateLimitPolicy{Name: "read", Limit: 10, Window: 30 * time.Second}, false},
+		{"1/1s", RateLimitPolicy{Name: "read", Limit: 1, Window: time.Second}, false},
+		{"5/1h30m", RateLimitPolicy{Name: "read", Limit: 5, Window: 90 * time.Minute}, false},
+		{"", RateLimitPolicy{}, true},
+		{"300", RateLimitPolicy{}, true},
+		{"abc/1m", RateLimitPolicy{}, true},
+		{"0/1m", RateLimitPolicy{}, true},
+		{"-1/1m", RateLimitPolicy{}, true},
+		{"300/", RateLimitPolicy{}, true},
+		{"300/60", RateLimitPolicy{}, true},
+		{"300/500ms", RateLimitPolicy{}, true},
+		{"300/-1m", RateLimitPolicy{}, true},
+		{" 300/1m", RateLimitPolicy{}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.spec, func(t *testing.T) {
+			got, err := ParseRateLimitPolicy("read", tc.spec)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseRateLimitPolicy(%q) err = %v, wantErr %v", tc.spec, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ParseRateLimitPolicy(%q) = %+v, want %+v", tc.spec, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLocalLimiterAllow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type step struct {
+		key           string
+		at            time.Duration // Offset dari start
+		wantAllowed   bool
+		wantRemaining int64
+		wantReset     time.Duration
+	}
+	cases := []struct {
+		name  string
+		limit int
+		steps []step
+	}{
+		{
+			name:  "blocks after limit until window resets",
+			limit: 2,
+			steps: []step{
+				{"a", 0, true, 1, time.Minute},
+				{"a", 10 * time.Second, true, 0, 50 * time.Second},
+				{"a", 20 * time.Second, false, 0, 40 * time.Second},
+				{"a", time.Minute, true, 1, time.Minute},
+			},
+		},
+		{
+			name:  "keys are independent",
+			limit: 1,
+			steps: []step{
+				{"a", 0, true, 0, time.Minute},
+				{"b", 0, true, 0, time.Minute},
+				{"a", time.Second, false, 0, 59 * time.Second},
+			},
+		},
+		{
+			name:  "blocked requests do not extend the window",
+			limit: 1,
+			steps: []step{
+				{"a", 0, true, 0, time.Minute},
+				{"a", 30 * time.Second, false, 0, 30 * time.Second},
+				{"a", 59 * time.Second, false, 0, time.Second},
+				{"a", 61 * time.Second, true, 0, time.Minute},
+			},
+		},
+		{
+			name:  "sweep keeps active windows",
+			limit: 1,
+			steps: []step{
+				{"a", 0, true, 0, time.Minute},
+				{"b", 50 * time.Second, true, 0, time.Minute},
+				{"b", 2 * time.Minute, true, 0, time.Minute},
+				{"b", 2*time.Minute + time.Second, false, 0, 59 * time.Second},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := newLocalLimiter(time.Minute)
+			l.lastSweep = start
+			for i, s := range tc.steps {
+				got := l.allow(s.key, tc.limit, start.Add(s.at))
+				if got.allowed != s.wantAllowed || got.remaining != s.wantRemaining || got.reset != s.wantReset {
+					t.Fatalf("step %d (%s @%v) = %+v, want allowed=%v remaining=%d reset=%v",
+						i, s.key, s.at, got, s.wantAllowed, s.wantRemaining, s.wantReset)
+				}
+				if !got.allowed && got.retryAfter != got.reset {
+					t.Errorf("step %d retryAfter = %v, want %v", i, got.retryAfter, got.reset)
+				}
+			}
+		})
+	}
+
+	// Window yang sudah lewat dibuang saat sweep agar map tidak tumbuh tanpa batas
+	t.Run("sweep removes expired windows", func(t *testing.T) {
+		l := newLocalLimiter(time.Minute)
+		l.lastSweep = start
+		l.allow("a", 1, start)
+		l.allow("b", 1, start.Add(90*time.Second))
+		if _, ok := l.counts["a"]; ok {
+			t.Error("expired window for a not swept")
+		}
+		if len(l.counts) != 1 {
+			t.Errorf("counts = %d entries, want 1", len(l.counts))
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/oklog/ulid/v2"
+
+	"khalif-comment/pkg/requestctx"
+
+)
+
+// RequestIDHeader: Dipakai ulang dari gateway / service pemanggil jika ada, supaya log antar service bisa dikorelasikan
+const RequestIDHeader = "X-Request-ID"
+
+// Batas panjang request ID dari luar, lebih dari ini dibuat ulang
+const maxRequestIDLength = 64
+
+// RequestID memasang request ID & IP client ke context request (requestctx) dan header response.
+// Dipasang paling awal agar middleware lain & usecase bisa membacanya
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = ulid.Make().String()
+		}
+
+		c.Header(RequestIDHeader, id)
+		c.Set("request_id", id)
+		c.Request = c.Request.WithContext(requestctx.With(c.Request.Context(), requestctx.Info{
+			RequestID: id,
+			IP:        c.ClientIP(),
+			ActorType: requestctx.ActorUser, // Anonim sampai middleware auth mengisi pelaku
+		}))
+
+		c.Next()
+	}
+}
+
+// validRequestID: Hanya karakter aman untuk log & header (huruf, angka, - _ . :)
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		c    Cursor
+	}{
+		{"nanos", Cursor{CreatedAt: time.Unix(1700000000, 123456789), ID: "01HGW2B8Y6Z1Q3X4V5T6R7S8P9"}},
+		{"epoch", Cursor{CreatedAt: time.Unix(0, 0), ID: "a"}},
+		{"id with colon", Cursor{CreatedAt: time.Unix(1700000000, 0), ID: "a:b:c"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Decode(tc.c.Encode())
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if !got.CreatedAt.Equal(tc.c.CreatedAt) || got.ID != tc.c.ID {
+				t.Errorf("round trip = %+v, want %+v", *got, tc.c)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	enc := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	cases := []struct {
+		name    string
+		src     string
+		wantNil bool
+		wantErr bool
+	}{
+		{"empty is first page", "", true, false},
+		{"valid", enc("1700000000000000000:abc"), false, false},
+		{"negative time", enc("-1:abc"), false, false},
+		{"not base64", "!!!", true, true},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte("1:ab")), true, true},
+		{"no separator", enc("1700000000"), true, true},
+		{"empty id", enc("1700000000:"), true, true},
+		{"non numeric time", enc("abc:def"), true, true},
+		{"time overflow", enc("99999999999999999999:abc"), true, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := Decode(tc.src)
+			if tc.wantErr {
+				if err != ErrInvalidCursor {
+					t.Fatalf("Decode(%q) err = %v, want ErrInvalidCursor", tc.src, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Decode(%q) err = %v", tc.src, err)
+			}
+			if (got == nil) != tc.wantNil {
+				t.Errorf("Decode(%q) = %+v, want nil %v", tc.src, got, tc.wantNil)
+			}
+		})
+	}
+}
+
+func TestNormalizeLimit(t *testing.T) {
+	cases := []struct{ in, want int }{
+		{-5, DefaultLimit},
+		{0, DefaultLimit},
+		{1, 1},
+		{MaxLimit, MaxLimit},
+		{MaxLimit + 1, MaxLimit},
+	}
+	for _, tc := range cases {
+		if got := NormalizeLimit(tc.in); got != tc.want {
+			t.Errorf("NormalizeLimit(%d) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package requestctx
+
+import "context"
+
+// Jenis pelaku sebuah aksi
+const (
+	ActorUser    = "user"    // Request dengan JWT
+	ActorService = "service" // Endpoint /internal dengan API key
+	ActorSystem  = "system"  // Background job / consumer event / CLI
+)
+
+// Info: Metadata request yang dibawa lewat context.Context sampai ke usecase (audit log, dst)
+type Info struct {
+	RequestID string
+	IP        string
+	ActorType string // ActorUser / ActorService / ActorSystem
+	ActorID   string // User ID atau nama service
+	ActorRole string // Role dari JWT, kosong untuk service / system
+}
+
+type ctxKey struct{}
+
+// With menyimpan info ke context
+func With(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, ctxKey{}, info)
+}
+
+// From: Info request, context tanpa info (background job) dianggap ActorSystem
+func From(ctx context.Context) Info {
+	if info, ok := ctx.Value(ctxKey{}).(Info); ok {
+		return info
+	}
+	return Info{ActorType: ActorSystem}
+}
+
+// WithActor mengisi pelaku pada info yang sudah ada di context
+func WithActor(ctx context.Context, actorType, actorID, role string) context.Context {
+	info := From(ctx)
+	info.ActorType = actorType
+	info.ActorID = actorID
+	info.ActorRole = role
+	return With(ctx, info)
+}
@@ -0,0 +1,271 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+
+	"khalif-comment/pkg/health"
+	"khalif-comment/pkg/logger"
+
+)
+
+// metrics dipublikasikan lewat expvar di /internal/debug/vars, per nama stream, contoh: {"stream": {"story_events.acked": 10, ...}}
+var metrics = expvar.NewMap("stream")
+
+// Message: Satu entry Redis Stream
+type Message struct {
+	ID         string
+	Values     map[string]interface{}
+	Deliveries int64 // 1 = pengiriman pertama
+}
+
+// String: Nilai field sebagai string, kosong jika tidak ada
+func (m Message) String(field string) string {
+	if v, ok := m.Values[field]; ok {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// Handler memproses satu pesan. Error biasa = diulang nanti, error Permanent = langsung ke dead-letter
+type Handler func(ctx context.Context, msg Message) error
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent menandai error yang tidak akan berhasil walau diulang (payload rusak, dst)
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Options: Konfigurasi consumer group
+type Options struct {
+	Stream     string
+	Group      string
+	Consumer   string        // Kosong = hostname-pid
+	DeadLetter string        // Kosong = <Stream>:dead
+	MaxRetries int64         // Jumlah pengiriman maksimal sebelum dipindah ke dead-letter
+	RetryIdle  time.Duration // Pesan pending lebih lama dari ini diambil alih & diulang
+	Block      time.Duration // Lama XREADGROUP menunggu pesan baru
+	Batch      int64
+}
+
+// Consumer: Redis Streams consumer group dengan ack, retry (XCLAIM pesan pending yang idle)
+// dan dead-letter. Pesan di-ack hanya setelah handler sukses, jadi handler harus idempotent
+type Consumer struct {
+	client  *redis.Client
+	monitor *health.RedisMonitor
+	opts    Options
+}
+
+func NewConsumer(client *redis.Client, monitor *health.RedisMonitor, opts Options) *Consumer {
+	if opts.Consumer == "" {
+		host, _ := os.Hostname()
+		opts.Consumer = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	if opts.DeadLetter == "" {
+		opts.DeadLetter = opts.Stream + ":dead"
+	}
+	if opts.MaxRetries < 1 {
+		opts.MaxRetries = 1
+	}
+	if opts.Block <= 0 {
+		opts.Block = 5 * time.Second
+	}
+	if opts.Batch <= 0 {
+		opts.Batch = 10
+	}
+	return &Consumer{client: client, monitor: monitor, opts: opts}
+}
+
+// Run blocking sampai ctx dibatalkan. Error Redis tidak menghentikan consumer, hanya ditunda sebentar
+func (c *Consumer) Run(ctx context.Context, handle Handler) error {
+	logger.Info("Stream consumer started",
+		zap.String("stream", c.opts.Stream),
+		zap.String("group", c.opts.Group),
+		zap.String("consumer", c.opts.Consumer),
+	)
+
+	groupReady := false
+	for ctx.Err() == nil {
+		if !c.monitor.Available() {
+			c.wait(ctx, time.Second)
+			continue
+		}
+
+		var err error
+		if !groupReady {
+			if err = c.ensureGroup(ctx); err == nil {
+				groupReady = true
+			}
+		}
+		if err == nil {
+			err = c.retryPending(ctx, handle)
+		}
+		if err == nil {
+			err = c.readNew(ctx, handle)
+		}
+
+		if err != nil && ctx.Err() == nil {
+			c.monitor.Report(err)
+			logger.Error("Stream consumer error, retrying", zap.String("stream", c.opts.Stream), zap.Error(err))
+			c.wait(ctx, time.Second)
+		}
+	}
+	return ctx.Err()
+}
+
+// ensureGroup: Group dibuat mulai dari pesan baru ($), MKSTREAM jika stream belum ada
+func (c *Consumer) ensureGroup(ctx context.Context) error {
+	err := c.client.XGroupCreateMkStream(ctx, c.opts.Stream, c.opts.Group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+// readNew: Pesan yang belum pernah dikirim ke consumer manapun di group ini
+func (c *Consumer) readNew(ctx context.Context, handle Handler) error {
+	streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    c.opts.Group,
+		Consumer: c.opts.Consumer,
+		Streams:  []string{c.opts.Stream, ">"},
+		Count:    c.opts.Batch,
+		Block:    c.opts.Block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil // Tidak ada pesan baru selama Block
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, s := range streams {
+		for _, m := range s.Messages {
+			c.process(ctx, handle, Message{ID: m.ID, Values: m.Values, Deliveries: 1})
+		}
+	}
+	return nil
+}
+
+// retryPending: Pesan yang gagal (atau consumer-nya mati) dan sudah idle > RetryIdle diambil alih.
+// Pesan yang sudah dikirim MaxRetries kali dipindah ke dead-letter
+func (c *Consumer) retryPending(ctx context.Context, handle Handler) error {
+	pending, err := c.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: c.opts.Stream,
+		Group:  c.opts.Group,
+		Idle:   c.opts.RetryIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  c.opts.Batch,
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	retries := map[string]int64{}
+	var ids []string
+	for _, p := range pending {
+		retries[p.ID] = p.RetryCount
+		ids = append(ids, p.ID)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// XCLAIM menaikkan delivery count, pesan yang sudah di-trim dari stream tidak dikembalikan
+	messages, err := c.client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   c.opts.Stream,
+		Group:    c.opts.Group,
+		Consumer: c.opts.Consumer,
+		MinIdle:  c.opts.RetryIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range messages {
+		msg := Message{ID: m.ID, Values: m.Values, Deliveries: retries[m.ID] + 1}
+		if retries[m.ID] >= c.opts.MaxRetries {
+			c.deadLetter(ctx, msg, "max retries exceeded")
+			continue
+		}
+		c.process(ctx, handle, msg)
+	}
+	return nil
+}
+
+func (c *Consumer) process(ctx context.Context, handle Handler, msg Message) {
+	err := handle(ctx, msg)
+	if err == nil {
+		c.ack(ctx, msg.ID)
+		metrics.Add(c.opts.Stream+".acked", 1)
+		return
+	}
+
+	var permanent permanentError
+	if errors.As(err, &permanent) || msg.Deliveries >= c.opts.MaxRetries {
+		c.deadLetter(ctx, msg, err.Error())
+		return
+	}
+
+	// Tidak di-ack: tetap pending dan diulang oleh retryPending setelah RetryIdle
+	metrics.Add(c.opts.Stream+".failed", 1)
+	logger.Error("Stream message failed, will retry",
+		zap.String("stream", c.opts.Stream),
+		zap.String("id", msg.ID),
+		zap.Int64("deliveries", msg.Deliveries),
+		zap.Error(err),
+	)
+}
+
+// deadLetter: Salin pesan beserta alasan gagal ke stream dead-letter, lalu ack agar tidak diulang lagi
+func (c *Consumer) deadLetter(ctx context.Context, msg Message, reason string) {
+	values := make(map[string]interface{}, len(msg.Values)+3)
+	for k, v := range msg.Values {
+		values[k] = v
+	}
+	values["dead_letter_id"] = msg.ID
+	values["dead_letter_deliveries"] = msg.Deliveries
+	values["dead_letter_error"] = reason
+
+	if err := c.client.XAdd(ctx, &redis.XAddArgs{Stream: c.opts.DeadLetter, Values: values}).Err(); err != nil {
+		// Jangan ack: lebih baik diulang daripada pesan hilang
+		logger.Error("Failed to dead-letter stream message", zap.String("stream", c.opts.Stream), zap.String("id", msg.ID), zap.Error(err))
+		return
+	}
+	c.ack(ctx, msg.ID)
+	metrics.Add(c.opts.Stream+".dead_lettered", 1)
+	logger.Error("Stream message moved to dead-letter",
+		zap.String("stream", c.opts.Stream),
+		zap.String("dead_letter", c.opts.DeadLetter),
+		zap.String("id", msg.ID),
+		zap.Int64("deliveries", msg.Deliveries),
+		zap.String("error", reason),
+	)
+}
+
+func (c *Consumer) ack(ctx context.Context, id string) {
+	if err := c.client.XAck(ctx, c.opts.Stream, c.opts.Group, id).Err(); err != nil {
+		// Pesan akan diulang setelah RetryIdle, handler idempotent jadi aman
+		logger.Error("Failed to ack stream message", zap.String("stream", c.opts.Stream), zap.String("id", id), zap.Error(err))
+	}
+}
+
+func (c *Consumer) wait(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,133 @@
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+
+)
+
+const (
+	zeroWidthNonJoiner = '\u200C'
+	zeroWidthJoiner    = '\u200D' // Bagian dari emoji gabungan (keluarga, profesi, dst), tidak boleh dibuang
+)
+
+// Options: Langkah pipeline normalisasi, nilai nol = langkah dimatikan.
+// Line ending selalu diseragamkan jadi \n
+type Options struct {
+	NFC            bool // Unicode NFC, "e" + U+0301 (combining acute) disamakan dengan U+00E9
+	StripInvisible bool // Karakter kontrol & format tak terlihat (zero-width space, bidi override, BOM, filler Hangul, dst)
+	Trim           bool // Spasi di awal / akhir teks dan di akhir setiap baris
+	MaxNewlines    int  // Maksimal newline berturut-turut (2 = satu baris kosong), 0 = tidak dibatasi
+}
+
+// Normalizer menjalankan langkah-langkah Options secara berurutan
+type Normalizer struct {
+	steps []func(string) string
+}
+
+func New(opts Options) *Normalizer {
+	n := &Normalizer{steps: []func(string) string{normalizeNewlines}}
+	if opts.NFC {
+		n.steps = append(n.steps, norm.NFC.String)
+	}
+	if opts.StripInvisible {
+		n.steps = append(n.steps, stripInvisible)
+	}
+	if opts.Trim {
+		n.steps = append(n.steps, trim)
+	}
+	if opts.MaxNewlines > 0 {
+		max := opts.MaxNewlines
+		n.steps = append(n.steps, func(s string) string { return collapseNewlines(s, max) })
+	}
+	return n
+}
+
+func (n *Normalizer) Normalize(s string) string {
+	for _, step := range n.steps {
+		s = step(s)
+	}
+	return s
+}
+
+// Graphemes: Panjang teks seperti yang dilihat user, emoji gabungan & huruf + accent dihitung satu
+func Graphemes(s string) int {
+	return uniseg.GraphemeClusterCount(s)
+}
+
+// IsBlank: Tidak ada satupun karakter yang terlihat (hanya spasi, joiner, variation selector)
+func IsBlank(s string) bool {
+	return strings.TrimFunc(s, isBlankRune) == ""
+}
+
+func normalizeNewlines(s string) string {
+	if !strings.ContainsAny(s, "\r\u2028\u2029") {
+		return s
+	}
+	return strings.NewReplacer("\r\n", "\n", "\r", "\n", "\u2028", "\n", "\u2029", "\n").Replace(s)
+}
+
+func stripInvisible(s string) string {
+	return strings.Map(func(r rune) rune {
+		if isInvisible(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// isInvisible: Kontrol (kecuali \n & \t), format (Cf) kecuali joiner & tag emoji bendera,
+// dan karakter "kosong" yang sering dipakai untuk lolos validasi
+func isInvisible(r rune) bool {
+	switch {
+	case r == '\n' || r == '\t':
+		return false
+	case r == zeroWidthJoiner || r == zeroWidthNonJoiner:
+		return false
+	case r >= 0xE0020 && r <= 0xE007F: // Tag sequence emoji bendera (England, Scotland, dst)
+		return false
+	case unicode.IsControl(r) || unicode.Is(unicode.Cf, r):
+		return true
+	}
+	switch r {
+	case '\u115F', '\u1160', '\u3164', '\uFFA0', // Filler Hangul
+		'\u2800',           // Braille kosong
+		'\u17B4', '\u17B5': // Vokal Khmer tak terlihat
+		return true
+	}
+	return false
+}
+
+func isBlankRune(r rune) bool {
+	return unicode.IsSpace(r) || r == zeroWidthJoiner || r == zeroWidthNonJoiner ||
+		unicode.Is(unicode.Variation_Selector, r) || isInvisible(r)
+}
+
+func trim(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(line, unicode.IsSpace)
+	}
+	return strings.TrimFunc(strings.Join(lines, "\n"), isBlankRune)
+}
+
+func collapseNewlines(s string, max int) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	run := 0
+	for _, r := range s {
+		if r == '\n' {
+			run++
+			if run > max {
+				continue
+			}
+		} else {
+			run = 0
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
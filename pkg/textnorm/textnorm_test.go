@@ -0,0 +1,113 @@
+package textnorm
+
+import (
+	"testing"
+
+)
+
+// Karakter tak terlihat ditulis sebagai escape agar kasusnya tetap terbaca di editor
+const (
+	family      = "\U0001F468\u200D\U0001F469\u200D\U0001F467" // Emoji keluarga, 3 orang digabung ZWJ
+	flagEngland = "\U0001F3F4\U000E0067\U000E0062\U000E0065\U000E006E\U000E0067\U000E007F"
+	thumbsDark  = "\U0001F44D\U0001F3FF" // Dengan modifier warna kulit
+)
+
+func TestNormalize(t *testing.T) {
+	all := Options{NFC: true, StripInvisible: true, Trim: true, MaxNewlines: 2}
+
+	cases := []struct {
+		name string
+		opts Options
+		src  string
+		want string
+	}{
+		{"plain", all, "halo dunia", "halo dunia"},
+		{"nfc", all, "cafe\u0301", "caf\u00E9"},
+		{"nfc off", Options{}, "cafe\u0301", "cafe\u0301"},
+		{"zero width space", all, "ha\u200Blo", "halo"},
+		{"bidi override", all, "abc\u202Edcba\u202C", "abcdcba"},
+		{"bidi isolate", all, "\u2066teks\u2069", "teks"},
+		{"bom", all, "\uFEFFhalo", "halo"},
+		{"soft hyphen", all, "ha\u00ADlo", "halo"},
+		{"control", all, "ha\x00l\x07o\x1b", "halo"},
+		{"hangul filler", all, "halo\u3164", "halo"},
+		{"braille blank", all, "\u2800halo", "halo"},
+		{"keeps tab", all, "a\tb", "a\tb"},
+		{"keeps zwj emoji", all, "keluarga " + family, "keluarga " + family},
+		{"keeps zwnj", all, "\u0645\u06CC\u200C\u062E\u0648\u0627\u0647\u0645", "\u0645\u06CC\u200C\u062E\u0648\u0627\u0647\u0645"},
+		{"keeps flag tags", all, flagEngland, flagEngland},
+		{"strip off", Options{}, "ha\u200Blo", "ha\u200Blo"},
+		{"trim", all, "  \t halo \n", "halo"},
+		{"trim line ends", all, "satu   \ndua\t", "satu\ndua"},
+		{"trim keeps indent", all, "satu\n  dua", "satu\n  dua"},
+		{"trim off", Options{}, "  halo  ", "  halo  "},
+		{"crlf", Options{}, "satu\r\ndua\rtiga", "satu\ndua\ntiga"},
+		{"line separator", Options{}, "satu\u2028dua\u2029tiga", "satu\ndua\ntiga"},
+		{"collapse newlines", all, "satu\n\n\n\ndua", "satu\n\ndua"},
+		{"collapse crlf", all, "satu\r\n\r\n\r\n\r\ndua", "satu\n\ndua"},
+		{"whitespace lines collapse", all, "satu  \n \n\n  \ndua", "satu\n\ndua"},
+		{"single newline kept", all, "satu\ndua", "satu\ndua"},
+		{"max newlines 1", Options{MaxNewlines: 1}, "satu\n\n\ndua", "satu\ndua"},
+		{"max newlines off", Options{}, "satu\n\n\n\ndua", "satu\n\n\n\ndua"},
+		{"only invisible", all, "\u200B\u200B\uFEFF", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := New(tc.opts).Normalize(tc.src); got != tc.want {
+				t.Errorf("Normalize(%+q)\n got  %+q\n want %+q", tc.src, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsBlank(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		want bool
+	}{
+		{"empty", "", true},
+		{"spaces", " \t\n ", true},
+		{"zero width only", "\u200B\u200C\u200D", true},
+		{"bom and nbsp", "\uFEFF\u00A0", true},
+		{"variation selector", "\uFE0F", true},
+		{"hangul filler", "\u3164\u115F", true},
+		{"text", "a", false},
+		{"text in zero width", "\u200Ba\u200B", false},
+		{"emoji", family, false},
+		{"dot", ".", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsBlank(tc.src); got != tc.want {
+				t.Errorf("IsBlank(%+q) = %v, want %v", tc.src, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGraphemes(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ascii", "halo", 4},
+		{"combining accent", "e\u0301", 1},
+		{"precomposed", "\u00E9", 1},
+		{"zwj family", family, 1},
+		{"skin tone", thumbsDark, 1},
+		{"tag flag", flagEngland, 1},
+		{"regional flag", "\U0001F1EE\U0001F1E9", 1},
+		{"crlf", "\r\n", 1},
+		{"mixed", "hi " + family + "!", 5},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Graphemes(tc.src); got != tc.want {
+				t.Errorf("Graphemes(%+q) = %d, want %d", tc.src, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+)
+
+func TestNotModified(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const etag = `W/"abc"`
+	modified := time.Date(2024, 5, 1, 10, 0, 0, 500_000_000, time.UTC)
+	httpDate := func(t time.Time) string { return t.UTC().Format(http.TimeFormat) }
+
+	cases := []struct {
+		name         string
+		lastModified time.Time
+		headers      map[string]string
+		want         bool
+	}{
+		{"no conditional headers", modified, nil, false},
+		{"etag match", modified, map[string]string{"If-None-Match": etag}, true},
+		{"strong etag matches weak", modified, map[string]string{"If-None-Match": `"abc"`}, true},
+		{"etag in list", modified, map[string]string{"If-None-Match": `"x", W/"abc" , "y"`}, true},
+		{"etag star", modified, map[string]string{"If-None-Match": " * "}, true},
+		{"etag mismatch", modified, map[string]string{"If-None-Match": `"other"`}, false},
+		{"etag mismatch ignores ims", modified, map[string]string{
+			"If-None-Match":     `"other"`,
+			"If-Modified-Since": httpDate(modified.Add(time.Hour)),
+		}, false},
+		{"ims same second", modified, map[string]string{"If-Modified-Since": httpDate(modified)}, true},
+		{"ims later", modified, map[string]string{"If-Modified-Since": httpDate(modified.Add(time.Hour))}, true},
+		{"ims earlier", modified, map[string]string{"If-Modified-Since": httpDate(modified.Add(-time.Second))}, false},
+		{"ims invalid", modified, map[string]string{"If-Modified-Since": "kemarin"}, false},
+		{"ims without last modified", time.Time{}, map[string]string{"If-Modified-Since": httpDate(modified)}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/comments", nil)
+			for k, v := range tc.headers {
+				c.Request.Header.Set(k, v)
+			}
+
+			if got := NotModified(c, etag, tc.lastModified); got != tc.want {
+				t.Fatalf("NotModified = %v, want %v", got, tc.want)
+			}
+			c.Writer.WriteHeaderNow()
+
+			wantStatus := http.StatusOK
+			if tc.want {
+				wantStatus = http.StatusNotModified
+			}
+			if w.Code != wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, wantStatus)
+			}
+			if got := w.Header().Get("ETag"); got != etag {
+				t.Errorf("ETag = %q, want %q", got, etag)
+			}
+			wantLM := ""
+			if !tc.lastModified.IsZero() {
+				wantLM = httpDate(tc.lastModified)
+			}
+			if got := w.Header().Get("Last-Modified"); got != wantLM {
+				t.Errorf("Last-Modified = %q, want %q", got, wantLM)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package utils
+
+import "net/http"
+
+// Kode error level HTTP / middleware. Kode spesifik domain ada di internal/domain/errors.go,
+// pesan untuk setiap kode ada di katalog pkg/i18n/locales
+const (
+	CodeBadRequest         = "BAD_REQUEST"
+	CodeInvalidRequest     = "INVALID_REQUEST" // Body tidak bisa di-bind / gagal validasi
+	CodeUnauthorized       = "UNAUTHORIZED"
+	CodeInvalidToken       = "INVALID_TOKEN"
+	CodeTokenExpired       = "TOKEN_EXPIRED"
+	CodeTokenRevoked       = "TOKEN_REVOKED"
+	CodeAuthUnavailable    = "AUTH_UNAVAILABLE" // Revocation tidak bisa dicek (REVOCATION_FAIL_CLOSED)
+	CodeInvalidAPIKey      = "INVALID_API_KEY"
+	CodeForbidden          = "FORBIDDEN"
+	CodeNotFound           = "NOT_FOUND"
+	CodeRateLimited        = "RATE_LIMITED"
+	CodeInternal           = "INTERNAL_ERROR"
+	CodeServiceUnavailable = "SERVICE_UNAVAILABLE"
+)
+
+// StatusErrorCode: Kode default untuk status HTTP, dipakai jika error tidak punya kode sendiri
+func StatusErrorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
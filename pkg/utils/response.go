@@ -1,12 +1,26 @@
 package utils
 
-import "github.com/gin-gonic/gin"
+import (
+	"github.com/gin-gonic/gin"
+
+	"khalif-comment/pkg/i18n"
+
+)
 
 type APIResponse struct {
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
-	Meta    interface{} `json:"meta,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Message   string       `json:"message,omitempty"`
+	Data      interface{}  `json:"data,omitempty"`
+	Meta      interface{}  `json:"meta,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	ErrorCode string       `json:"error_code,omitempty" example:"COMMENT_NOT_FOUND"` // Stabil, dipakai client untuk membedakan error
+	Fields    []FieldError `json:"fields,omitempty"`                                 // Error per field untuk body yang gagal validasi
+}
+
+// FieldError: Satu field yang tidak valid, message sudah diterjemahkan
+type FieldError struct {
+	Field   string `json:"field" example:"content"`
+	Code    string `json:"code" example:"required"`
+	Message string `json:"message" example:"content wajib diisi"`
 }
 
 func SuccessResponse(c *gin.Context, code int, data interface{}) {
@@ -22,14 +36,58 @@ func SuccessResponseWithMeta(c *gin.Context, code int, data interface{}, meta in
 	})
 }
 
+// SuccessMessage: message berupa key katalog i18n (misal COMMENT_DELETED), key yang tidak dikenal dikirim apa adanya
 func SuccessMessage(c *gin.Context, code int, message string) {
 	c.JSON(code, APIResponse{
-		Message: message,
+		Message: i18n.T(Language(c), message, nil),
 	})
 }
 
+// ErrorResponse: error_code diisi sesuai status HTTP (lihat StatusErrorCode)
 func ErrorResponse(c *gin.Context, code int, message string) {
-	c.JSON(code, APIResponse{
-		Error: message,
+	ErrorResponseWithCode(c, code, StatusErrorCode(code), message)
+}
+
+// ErrorResponseWithCode: Pesan diambil dari katalog i18n berdasarkan errorCode sesuai bahasa client,
+// message hanya dipakai jika kode belum ada di katalog
+func ErrorResponseWithCode(c *gin.Context, status int, errorCode string, message string) {
+	errorResponse(c, status, errorCode, message, nil)
+}
+
+// ErrorResponseWithFields: Seperti ErrorResponseWithCode, ditambah error per field (lihat NewFieldError)
+func ErrorResponseWithFields(c *gin.Context, status int, errorCode string, message string, fields []FieldError) {
+	errorResponse(c, status, errorCode, message, fields)
+}
+
+// AbortWithError: Untuk middleware, menghentikan chain handler lalu mengirim error
+func AbortWithError(c *gin.Context, status int, errorCode string, message string) {
+	c.Abort()
+	ErrorResponseWithCode(c, status, errorCode, message)
+}
+
+func errorResponse(c *gin.Context, status int, errorCode string, message string, fields []FieldError) {
+	if translated, ok := i18n.Lookup(Language(c), errorCode, nil); ok {
+		message = translated
+	}
+	c.JSON(status, APIResponse{
+		Error:     message,
+		ErrorCode: errorCode,
+		Fields:    fields,
 	})
-}
\ No newline at end of file
+}
+
+// languageKey: Bahasa hasil negosiasi di-cache per request
+const languageKey = "lang"
+
+// Language: Bahasa response (id / en) dari header Accept-Language. Response yang berisi
+// pesan terjemahan diberi Content-Language & Vary agar cache tidak mencampur bahasa
+func Language(c *gin.Context) string {
+	if lang := c.GetString(languageKey); lang != "" {
+		return lang
+	}
+	lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	c.Set(languageKey, lang)
+	c.Header("Content-Language", lang)
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	return lang
+}
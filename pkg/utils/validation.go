@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"khalif-comment/pkg/i18n"
+
+)
+
+var registerFieldNames sync.Once
+
+// UseJSONFieldNames membuat validator gin melaporkan nama field sesuai tag json
+// (content, story_ids[0]) alih-alih nama struct Go (Content, StoryIDs[0])
+func UseJSONFieldNames() {
+	registerFieldNames.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return f.Name
+			}
+			return name
+		})
+	})
+}
+
+// ValidationErrorResponse: 400 INVALID_REQUEST untuk error dari ShouldBind*, dengan pesan
+// per field yang sudah diterjemahkan. Pesan mentah validator / decoder JSON tidak dikirim ke client
+func ValidationErrorResponse(c *gin.Context, err error) {
+	lang := Language(c)
+
+	var fields []FieldError
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fe := range validationErrs {
+			fields = append(fields, translateField(lang, fieldPath(fe.Namespace()), fe.Tag(), map[string]string{"param": fe.Param()}))
+		}
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		fields = append(fields, FieldError{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: i18n.T(lang, "validation.type", map[string]string{"field": typeErr.Field}),
+		})
+	}
+
+	errorResponse(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request body", fields)
+}
+
+// NewFieldError: Error per field dengan pesan "validation.<code>" sesuai bahasa client,
+// params mengisi placeholder selain {field}
+func NewFieldError(c *gin.Context, field, code string, params map[string]string) FieldError {
+	return translateField(Language(c), field, code, params)
+}
+
+func translateField(lang, field, code string, params map[string]string) FieldError {
+	args := map[string]string{"field": field}
+	for k, v := range params {
+		args[k] = v
+	}
+	msg, ok := i18n.Lookup(lang, "validation."+code, args)
+	if !ok {
+		msg = i18n.T(lang, "validation.invalid", args)
+	}
+	return FieldError{Field: field, Code: code, Message: msg}
+}
+
+// fieldPath: "CreateCommentRequest.story_ids[0]" -> "story_ids[0]"
+func fieldPath(namespace string) string {
+	if _, rest, ok := strings.Cut(namespace, "."); ok {
+		return rest
+	}
+	return namespace
+}